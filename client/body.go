@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrResponseTooLarge is raised when the response body exceeds the limit
+	// set with MaxResponseBytes.
+	ErrResponseTooLarge = errors.New("Response body too large")
+	// ErrReadIdleTimeout is raised when no data has been read from the response
+	// body for the duration set with ReadIdleTimeout.
+	ErrReadIdleTimeout = errors.New("Response body read idle timeout")
+)
+
+// MaxResponseBytes limits the size of the response body. Reading past the
+// limit fails with ErrResponseTooLarge, and so does the request when the
+// announced Content-Length already exceeds it.
+func MaxResponseBytes(n int64) RequestOption {
+	return func(req *Request) error {
+		req.maxResponseBytes = &n
+		return nil
+	}
+}
+
+// ReadIdleTimeout aborts the reading of the response body, failing with
+// ErrReadIdleTimeout, when no data has been received for the given duration. It
+// is meant to guard streaming bodies, which a Timeout would cut regardless of
+// their progress.
+func ReadIdleTimeout(duration time.Duration) RequestOption {
+	return func(req *Request) error {
+		req.readIdleTimeout = &duration
+		return nil
+	}
+}
+
+// limitedReader reads at most `remaining` bytes, failing with
+// ErrResponseTooLarge if there is more to read. Once failed, it keeps failing
+// without reading any further.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+
+	// Reading one more byte than allowed tells apart a body of exactly the
+	// limit from a larger one.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(l.remaining)
+	l.remaining = 0
+	l.exceeded = true
+	return n, ErrResponseTooLarge
+}
+
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func newLimitedBody(body io.ReadCloser, n int64) io.ReadCloser {
+	return limitedBody{&limitedReader{r: body, remaining: n}, body}
+}
+
+// idleBody cancels the request once no data has been read for a while.
+type idleBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	idle    int32
+	cancel  context.CancelFunc
+}
+
+// wrap starts watching the given body.
+func (b *idleBody) wrap(body io.ReadCloser) io.ReadCloser {
+	b.ReadCloser = body
+	b.timer = time.AfterFunc(b.timeout, func() {
+		atomic.StoreInt32(&b.idle, 1)
+		b.cancel()
+	})
+	return b
+}
+
+// release cancels the request when the body won't be wrapped.
+func (b *idleBody) release() {
+	if b != nil {
+		b.cancel()
+	}
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if atomic.LoadInt32(&b.idle) == 1 {
+		return n, ErrReadIdleTimeout
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
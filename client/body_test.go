@@ -0,0 +1,129 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase           string
+		size               int
+		chunked            bool
+		expectedRequestErr error
+		expectedReadErr    error
+	}{
+		{
+			testcase: "should read a body of exactly the limit",
+			size:     64,
+		},
+		{
+			testcase:           "should fail the request when the content-length is too large",
+			size:               65,
+			expectedRequestErr: client.ErrResponseTooLarge,
+		},
+		{
+			testcase:        "should fail the read when a chunked body is too large",
+			size:            1024,
+			chunked:         true,
+			expectedReadErr: client.ErrResponseTooLarge,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if test.chunked {
+						w.(http.Flusher).Flush()
+					}
+					_, _ = w.Write([]byte(strings.Repeat("a", test.size)))
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			resp, err := cli.Get(ctx, server.URL, client.MaxResponseBytes(64))
+			if !errors.Is(err, test.expectedRequestErr) {
+				t.Fatalf("expected %v got %v", test.expectedRequestErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			if !errors.Is(err, test.expectedReadErr) {
+				t.Fatalf("expected %v got %v", test.expectedReadErr, err)
+			}
+			if len(b) > 64 {
+				t.Fatalf("expected at most %d bytes got %d", 64, len(b))
+			}
+		})
+	}
+}
+
+func TestMaxResponseBytesValidationErrors(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(validationErrorResponse))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	_, err := cli.Post(ctx, server.URL,
+		client.MaxResponseBytes(16),
+		client.FailOn(client.HasValidationErrors(someError)),
+	)
+	if !errors.Is(err, client.ErrResponseTooLarge) {
+		t.Fatalf("expected %v got %v", client.ErrResponseTooLarge, err)
+	}
+}
+
+func TestReadIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 3; i++ {
+				_, _ = w.Write([]byte("tick"))
+				w.(http.Flusher).Flush()
+				<-time.After(10 * time.Millisecond)
+			}
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	resp, err := cli.Get(ctx, server.URL, client.ReadIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, client.ErrReadIdleTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrReadIdleTimeout, err)
+	}
+	if string(b) != "tickticktick" {
+		t.Fatalf("expected the streamed data to be read, got %q", string(b))
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wrapp/instrumentation/logs"
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrCircuitOpen is raised when the circuit breaker of the upstream host is
+	// open and the request has not been sent.
+	ErrCircuitOpen = errors.New("Circuit open")
+	// ErrInvalidCircuitBreaker is raised when a circuit breaker is configured
+	// with a failure threshold of 0 or an open timeout which is not positive.
+	ErrInvalidCircuitBreaker = errors.New("Invalid circuit breaker")
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker per upstream host. The circuit
+// opens after failureThreshold consecutive failures (transport errors or 5xx),
+// rejecting every request with ErrCircuitOpen. Once openTimeout has elapsed, a
+// single probe request is let through: the circuit closes if it succeeds and
+// opens again otherwise.
+func WithCircuitBreaker(failureThreshold uint, openTimeout time.Duration) Option {
+	return func(c *client) error {
+		if failureThreshold == 0 || openTimeout <= 0 {
+			return fmt.Errorf("%w: %d failures with an open timeout of %v",
+				ErrInvalidCircuitBreaker, failureThreshold, openTimeout)
+		}
+		c.breakers = &circuitBreakers{
+			failureThreshold: failureThreshold,
+			openTimeout:      openTimeout,
+			hosts:            make(map[string]*circuitBreaker),
+		}
+		return nil
+	}
+}
+
+type circuitBreakers struct {
+	mu               sync.Mutex
+	failureThreshold uint
+	openTimeout      time.Duration
+	hosts            map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state    circuitState
+	failures uint
+	openedAt time.Time
+	probing  bool
+}
+
+// allow returns ErrCircuitOpen if a request to the host must not be sent.
+func (b *circuitBreakers) allow(ctx context.Context, host string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.hosts[host]
+	if !ok {
+		return nil
+	}
+
+	if breaker.state == circuitOpen && time.Since(breaker.openedAt) >= b.openTimeout {
+		b.transition(ctx, host, breaker, circuitHalfOpen)
+	}
+
+	switch {
+	case breaker.state == circuitOpen,
+		breaker.state == circuitHalfOpen && breaker.probing:
+		trace.FromContext(ctx).Annotate([]trace.Attribute{
+			trace.StringAttribute("host", host),
+		}, "circuit breaker rejected the request")
+		return ErrCircuitOpen
+	case breaker.state == circuitHalfOpen:
+		breaker.probing = true
+	}
+
+	return nil
+}
+
+// record updates the circuit of the host with the outcome of an attempt.
+func (b *circuitBreakers) record(ctx context.Context, host string, attempt Attempt) {
+	if b == nil {
+		return
+	}
+
+	failed := attempt.Response == nil ||
+		attempt.Response.StatusCode >= http.StatusInternalServerError
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.hosts[host]
+	var notSent notSentError
+	if errors.Is(attempt.Err, context.Canceled) || errors.As(attempt.Err, &notSent) ||
+		errors.Is(attempt.Err, ErrBulkheadFull) || errors.Is(attempt.Err, ErrRateLimited) {
+		// The caller gave up or the request was not sent, this says nothing
+		// about the health of the host.
+		if ok {
+			breaker.probing = false
+		}
+		return
+	}
+
+	if !ok {
+		if !failed {
+			return
+		}
+		breaker = &circuitBreaker{}
+		b.hosts[host] = breaker
+	}
+
+	if !failed {
+		breaker.failures = 0
+		breaker.probing = false
+		if breaker.state != circuitClosed {
+			b.transition(ctx, host, breaker, circuitClosed)
+		}
+		return
+	}
+
+	breaker.failures++
+	if breaker.state == circuitHalfOpen || breaker.failures >= b.failureThreshold {
+		breaker.probing = false
+		breaker.openedAt = time.Now()
+		if breaker.state != circuitOpen {
+			b.transition(ctx, host, breaker, circuitOpen)
+		}
+	}
+}
+
+func (b *circuitBreakers) transition(ctx context.Context, host string, breaker *circuitBreaker, to circuitState) {
+	from := breaker.state
+	breaker.state = to
+
+	logs.Warn(ctx).
+		Str("host", host).
+		Str("from", from.String()).
+		Str("to", to.String()).
+		Msg("circuit breaker state changed")
+	trace.FromContext(ctx).Annotate([]trace.Attribute{
+		trace.StringAttribute("host", host),
+		trace.StringAttribute("from", from.String()),
+		trace.StringAttribute("to", to.String()),
+	}, "circuit breaker state changed")
+}
@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	counter := 0
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			if !healthy {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	openTimeout := 50 * time.Millisecond
+	cli, _ := client.New(client.WithCircuitBreaker(2, openTimeout))
+
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get(ctx, server.URL)
+		if err != nil {
+			t.Fatalf("expected no errors got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	_, err := cli.Get(ctx, server.URL)
+	if !errors.Is(err, client.ErrCircuitOpen) {
+		t.Fatalf("expected %v got %v", client.ErrCircuitOpen, err)
+	}
+	if counter != 2 {
+		t.Fatalf("expected the open circuit to not send the request, got %d requests", counter)
+	}
+
+	<-time.After(openTimeout)
+	healthy = true
+
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get(ctx, server.URL)
+		if err != nil {
+			t.Fatalf("expected the circuit to close, got %v", err)
+		}
+		resp.Body.Close()
+	}
+	if counter != 4 {
+		t.Fatalf("expected %d got %d", 4, counter)
+	}
+}
+
+func TestCircuitBreakerStopsRetries(t *testing.T) {
+	ctx := context.Background()
+
+	counter := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithCircuitBreaker(2, time.Minute))
+	_, err := cli.Get(ctx, server.URL, client.WithRetryPolicy(client.RetryOnServerErrors(5)))
+	if !errors.Is(err, client.ErrCircuitOpen) {
+		t.Fatalf("expected %v got %v", client.ErrCircuitOpen, err)
+	}
+	if counter != 2 {
+		t.Fatalf("expected %d got %d", 2, counter)
+	}
+}
+
+func TestInvalidCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		testcase         string
+		failureThreshold uint
+		openTimeout      time.Duration
+	}{
+		{testcase: "zero failure threshold", failureThreshold: 0, openTimeout: time.Second},
+		{testcase: "zero open timeout", failureThreshold: 1, openTimeout: 0},
+		{testcase: "negative open timeout", failureThreshold: 1, openTimeout: -time.Second},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			_, err := client.New(client.WithCircuitBreaker(test.failureThreshold, test.openTimeout))
+			if !errors.Is(err, client.ErrInvalidCircuitBreaker) {
+				t.Fatalf("expected %v got %v", client.ErrInvalidCircuitBreaker, err)
+			}
+		})
+	}
+}
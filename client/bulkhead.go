@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrBulkheadFull is raised when a request can't be sent because the
+	// maximum number of concurrent requests is reached and too many requests
+	// are already waiting for their turn.
+	ErrBulkheadFull = errors.New("Bulkhead full")
+	// ErrInvalidBulkhead is raised when a bulkhead is configured with less than
+	// one concurrent request or a negative number of queued requests.
+	ErrInvalidBulkhead = errors.New("Invalid bulkhead")
+)
+
+// WithMaxConcurrent caps the number of concurrent requests sent to the host
+// (as in the url, eg. "api.example.com" or "api.example.com:8080") to n. An
+// empty host caps the client as a whole. A request is in flight until its
+// response body is closed. Up to n requests wait for their turn, as long as
+// their context allows, the others fail with ErrBulkheadFull (see
+// WithMaxQueued).
+func WithMaxConcurrent(host string, n int) Option {
+	return func(c *client) error {
+		if n < 1 {
+			return fmt.Errorf("%w: %d concurrent requests", ErrInvalidBulkhead, n)
+		}
+		b := c.bulkheads.get(host)
+		b.slots = make(chan struct{}, n)
+		if !b.maxQueuedSet {
+			b.maxQueued = int32(n)
+		}
+		return nil
+	}
+}
+
+// WithMaxQueued sets how many requests to the host can wait for their turn
+// when the maximum set by WithMaxConcurrent is reached.
+func WithMaxQueued(host string, n int) Option {
+	return func(c *client) error {
+		if n < 0 {
+			return fmt.Errorf("%w: %d queued requests", ErrInvalidBulkhead, n)
+		}
+		b := c.bulkheads.get(host)
+		b.maxQueued = int32(n)
+		b.maxQueuedSet = true
+		return nil
+	}
+}
+
+// InFlight returns the number of requests in flight and waiting for their turn
+// to the host (or the client as a whole for an empty host), as limited by
+// WithMaxConcurrent.
+func InFlight(c Client, host string) (inFlight int, queued int) {
+	cli, ok := c.(client)
+	if !ok {
+		return 0, 0
+	}
+
+	b, ok := cli.bulkheads.hosts[host]
+	if !ok || b.slots == nil {
+		return 0, 0
+	}
+	return len(b.slots), int(atomic.LoadInt32(&b.queued))
+}
+
+type bulkheads struct {
+	hosts map[string]*bulkhead
+}
+
+func (b *bulkheads) get(host string) *bulkhead {
+	if b.hosts == nil {
+		b.hosts = make(map[string]*bulkhead)
+	}
+	if _, ok := b.hosts[host]; !ok {
+		b.hosts[host] = &bulkhead{}
+	}
+	return b.hosts[host]
+}
+
+// acquire takes a slot in the bulkheads of the client and the url, and returns
+// the function releasing them.
+func (b *bulkheads) acquire(ctx context.Context, u *url.URL) (func(), error) {
+	hosts := []string{"", u.Host}
+	if u.Hostname() != u.Host {
+		hosts = append(hosts, u.Hostname())
+	}
+
+	var taken []*bulkhead
+	release := func() {
+		for _, bulkhead := range taken {
+			bulkhead.release()
+		}
+	}
+	for _, host := range hosts {
+		bulkhead, ok := b.hosts[host]
+		if !ok || bulkhead.slots == nil {
+			continue
+		}
+		if err := bulkhead.acquire(ctx); err != nil {
+			release()
+			return nil, err
+		}
+		taken = append(taken, bulkhead)
+	}
+	return release, nil
+}
+
+type bulkhead struct {
+	slots        chan struct{}
+	queued       int32
+	maxQueued    int32
+	maxQueuedSet bool
+}
+
+func (b *bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if atomic.AddInt32(&b.queued, 1) > b.maxQueued {
+		atomic.AddInt32(&b.queued, -1)
+		return ErrBulkheadFull
+	}
+	defer atomic.AddInt32(&b.queued, -1)
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return timeoutError{cause: ctx.Err()}
+	}
+}
+
+func (b *bulkhead) release() {
+	<-b.slots
+}
+
+// releaseOnClose releases the bulkhead slots of a request once its response
+// body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	defer b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
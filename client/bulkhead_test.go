@@ -0,0 +1,128 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestMaxConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(
+		client.WithMaxConcurrent("", 2),
+		client.WithMaxQueued("", 1),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := cli.Get(ctx, server.URL)
+			if err != nil {
+				t.Errorf("expected no errors got %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		inFlight, queued := client.InFlight(cli, "")
+		if inFlight == 2 && queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 requests in flight and 1 queued got %d and %d", inFlight, queued)
+		}
+		<-time.After(time.Millisecond)
+	}
+
+	_, err := cli.Get(ctx, server.URL)
+	if !errors.Is(err, client.ErrBulkheadFull) {
+		t.Fatalf("expected %v got %v", client.ErrBulkheadFull, err)
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	if inFlight, queued := client.InFlight(cli, ""); inFlight != 0 || queued != 0 {
+		t.Fatalf("expected the slots to be released got %d in flight and %d queued", inFlight, queued)
+	}
+}
+
+func TestBulkheadDoesNotOpenCircuit(t *testing.T) {
+	ctx := context.Background()
+
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-unblock
+		}))
+	defer server.Close()
+	defer close(unblock)
+
+	cli, _ := client.New(
+		client.WithCircuitBreaker(2, time.Minute),
+		client.WithMaxConcurrent("", 1),
+		client.WithMaxQueued("", 0),
+	)
+
+	// The slot is held until the body is closed.
+	resp, err := cli.Get(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := cli.Get(ctx, server.URL)
+		if !errors.Is(err, client.ErrBulkheadFull) {
+			t.Fatalf("expected %v got %v", client.ErrBulkheadFull, err)
+		}
+	}
+	resp.Body.Close()
+
+	_, err = cli.Get(ctx, server.URL, client.Timeout(10*time.Millisecond))
+	if errors.Is(err, client.ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to remain closed got %v", err)
+	}
+}
+
+func TestInvalidBulkhead(t *testing.T) {
+	tests := []struct {
+		testcase string
+		option   client.Option
+	}{
+		{testcase: "zero concurrent requests", option: client.WithMaxConcurrent("", 0)},
+		{testcase: "negative concurrent requests", option: client.WithMaxConcurrent("", -1)},
+		{testcase: "negative queued requests", option: client.WithMaxQueued("", -1)},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			_, err := client.New(test.option)
+			if !errors.Is(err, client.ErrInvalidBulkhead) {
+				t.Fatalf("expected %v got %v", client.ErrInvalidBulkhead, err)
+			}
+		})
+	}
+}
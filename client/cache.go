@@ -0,0 +1,345 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wrapp/instrumentation/logs"
+	"go.opencensus.io/trace"
+)
+
+// maxCachedBodyBytes is the size above which a response is not cached.
+const maxCachedBodyBytes = 1 << 20
+
+// CachedResponse is a response stored in a CacheStore.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Vary holds the values of the request headers the response varies on.
+	Vary http.Header
+	// Expires is the time until which the response is fresh. Once stale, it is
+	// revalidated if it has an ETag or a Last-Modified.
+	Expires time.Time
+}
+
+// CacheStore stores the cached responses. It must be safe for concurrent use
+// and must not modify the stored responses.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// WithCache enables an in-process cache of the GET responses, following the
+// Cache-Control max-age / no-cache / no-store directives (or Expires) and
+// revalidating stale responses with their ETag or Last-Modified. The responses
+// to requests with credentials (Authorization or Cookie) are only shared between
+// requests with the same credentials.
+func WithCache(store CacheStore) Option {
+	return WithInterceptors(cacheInterceptor(store))
+}
+
+func cacheInterceptor(store CacheStore) Interceptor {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet || hasDirective(req.Header, "no-store") {
+				return next.Do(req)
+			}
+
+			key := cacheKey(req)
+			cached, ok := store.Get(key)
+			if ok && !cached.matches(req) {
+				ok = false
+			}
+
+			if ok && time.Now().Before(cached.Expires) && !hasDirective(req.Header, "no-cache") {
+				traceCache(req, "hit")
+				return cached.response(req), nil
+			}
+
+			if ok {
+				if etag := cached.Header.Get("ETag"); etag != "" {
+					req.Header.Set("If-None-Match", etag)
+				}
+				if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+					req.Header.Set("If-Modified-Since", lastModified)
+				}
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok && resp.StatusCode == http.StatusNotModified {
+				drain(resp)
+				cached = cached.refreshed(resp)
+				store.Set(key, cached)
+				traceCache(req, "revalidated")
+				return cached.response(req), nil
+			}
+
+			traceCache(req, "miss")
+			return save(store, key, req, resp)
+		})
+	}
+}
+
+// cacheKey is the URL of the request, along with a hash of its credentials so
+// that the responses are never shared between users.
+func cacheKey(req *http.Request) string {
+	key := req.URL.String()
+	credentials := req.Header.Values("Authorization")
+	credentials = append(credentials, req.Header.Values("Cookie")...)
+	if len(credentials) == 0 {
+		return key
+	}
+	return key + " " + hashCredentials(credentials)
+}
+
+// hashCredentials hashes the credentials so that they are not kept around in
+// clear.
+func hashCredentials(credentials []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(credentials, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func traceCache(req *http.Request, status string) {
+	trace.FromContext(req.Context()).AddAttributes(trace.StringAttribute("http.cache", status))
+	logs.Debug(req.Context()).
+		Str("cache", status).
+		Str("url", redactURL(req.URL.String())).
+		Msg("http cache")
+}
+
+// save stores the response if it is cacheable and returns it with a body that
+// can still be read.
+func save(store CacheStore, key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	if !isCacheable(resp) {
+		return resp, nil
+	}
+
+	// Reading one more byte than the limit tells a response at the limit from a
+	// larger one, the bytes read are given back along with the rest of the body.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodyBytes+1))
+	if err == nil && len(body) > maxCachedBodyBytes {
+		resp.Body = limitedBody{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		Vary:       http.Header{},
+		Expires:    expires(resp.Header),
+	}
+	for _, field := range varyFields(resp.Header) {
+		cached.Vary.Set(field, req.Header.Get(field))
+	}
+	store.Set(key, cached)
+
+	return cached.response(req), nil
+}
+
+func isCacheable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+
+	if hasDirective(resp.Header, "no-store") {
+		return false
+	}
+	for _, field := range varyFields(resp.Header) {
+		if field == "*" {
+			return false
+		}
+	}
+
+	// Without an explicit freshness nor a validator, the response would never be
+	// used.
+	_, hasMaxAge := maxAge(resp.Header)
+	return hasMaxAge || resp.Header.Get("Expires") != "" ||
+		resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// expires returns the time until which a response is fresh.
+func expires(header http.Header) time.Time {
+	now := time.Now()
+	if hasDirective(header, "no-cache") {
+		return now
+	}
+
+	if age, ok := maxAge(header); ok {
+		if current, err := strconv.Atoi(header.Get("Age")); err == nil && current > 0 {
+			age -= time.Duration(current) * time.Second
+		}
+		return now.Add(age)
+	}
+
+	if date, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return date
+	}
+	return now
+}
+
+// hasDirective reports whether the Cache-Control header holds the directive.
+func hasDirective(header http.Header, directive string) bool {
+	for _, d := range cacheControl(header) {
+		if strings.EqualFold(d, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+func maxAge(header http.Header) (time.Duration, bool) {
+	for _, d := range cacheControl(header) {
+		name, value, ok := strings.Cut(d, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+func cacheControl(header http.Header) []string {
+	var directives []string
+	for _, d := range strings.Split(header.Get("Cache-Control"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			directives = append(directives, d)
+		}
+	}
+	return directives
+}
+
+func varyFields(header http.Header) []string {
+	var fields []string
+	for _, field := range strings.Split(header.Get("Vary"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, http.CanonicalHeaderKey(field))
+		}
+	}
+	return fields
+}
+
+// matches reports whether the request headers the response varies on are the
+// same.
+func (c *CachedResponse) matches(req *http.Request) bool {
+	for field := range c.Vary {
+		if req.Header.Get(field) != c.Vary.Get(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshed returns a copy of the response updated with the headers of a 304.
+func (c *CachedResponse) refreshed(resp *http.Response) *CachedResponse {
+	refreshed := *c
+	refreshed.Header = c.Header.Clone()
+	for _, field := range []string{"Cache-Control", "Date", "Expires", "ETag", "Last-Modified", "Age"} {
+		if values, ok := resp.Header[field]; ok {
+			refreshed.Header[field] = values
+		}
+	}
+	refreshed.Expires = expires(refreshed.Header)
+	return &refreshed
+}
+
+func (c *CachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(c.StatusCode) + " " + http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// lruCache is an in-memory CacheStore evicting the least recently used
+// responses.
+type lruCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    *list.List
+	keys       map[string]*list.Element
+}
+
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewLRUCache creates an in-memory CacheStore holding up to maxEntries
+// responses.
+func NewLRUCache(maxEntries int) CacheStore {
+	return &lruCache{
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		keys:       make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.keys[key]
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(elem)
+	return elem.Value.(*lruEntry).resp, true
+}
+
+func (c *lruCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[key]; ok {
+		elem.Value.(*lruEntry).resp = resp
+		c.entries.MoveToFront(elem)
+		return
+	}
+
+	c.keys[key] = c.entries.PushFront(&lruEntry{key: key, resp: resp})
+	for c.entries.Len() > c.maxEntries {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.keys, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[key]; ok {
+		c.entries.Remove(elem)
+		delete(c.keys, key)
+	}
+}
@@ -0,0 +1,244 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase        string
+		header          http.Header
+		expectedCounter int
+		expectedBodies  int
+	}{
+		{
+			testcase:        "should serve a fresh response from the cache",
+			header:          http.Header{"Cache-Control": {"max-age=60"}},
+			expectedCounter: 1,
+			expectedBodies:  1,
+		},
+		{
+			testcase:        "should revalidate a stale response with its etag",
+			header:          http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			expectedCounter: 3,
+			expectedBodies:  1,
+		},
+		{
+			testcase:        "should revalidate a stale response with its last-modified",
+			header:          http.Header{"Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"}},
+			expectedCounter: 3,
+			expectedBodies:  1,
+		},
+		{
+			testcase:        "should not cache a no-store response",
+			header:          http.Header{"Cache-Control": {"no-store, max-age=60"}},
+			expectedCounter: 3,
+			expectedBodies:  3,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			counter, bodies := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					counter++
+					for k, v := range test.header {
+						w.Header()[k] = v
+					}
+					lastModified := test.header.Get("Last-Modified")
+					if r.Header.Get("If-None-Match") == `"v1"` ||
+						(lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified) {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					bodies++
+					_, _ = w.Write([]byte("cached"))
+				}))
+			defer server.Close()
+
+			cli, _ := client.New(client.WithCache(client.NewLRUCache(10)))
+			for i := 0; i < 3; i++ {
+				resp, err := cli.Get(ctx, server.URL)
+				if err != nil {
+					t.Fatalf("expected no errors got %v", err)
+				}
+				b, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				if string(b) != "cached" {
+					t.Fatalf("expected %s got %s", "cached", string(b))
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("expected status %d got %d", http.StatusOK, resp.StatusCode)
+				}
+			}
+
+			if counter != test.expectedCounter {
+				t.Fatalf("expected %d requests got %d", test.expectedCounter, counter)
+			}
+			if bodies != test.expectedBodies {
+				t.Fatalf("expected %d bodies got %d", test.expectedBodies, bodies)
+			}
+		})
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithCache(client.NewLRUCache(10)))
+	for _, token := range []string{"bob", "alice", "bob"} {
+		resp, err := cli.Get(ctx, server.URL, client.AuthorizationBearer(token))
+		if err != nil {
+			t.Fatalf("expected no errors got %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if expected := "Bearer " + token; string(b) != expected {
+			t.Fatalf("expected %s got %s", expected, string(b))
+		}
+	}
+}
+
+func TestCacheLargeResponse(t *testing.T) {
+	ctx := context.Background()
+	large := bytes.Repeat([]byte("a"), 2<<20)
+
+	counter := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write(large)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithCache(client.NewLRUCache(10)))
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get(ctx, server.URL)
+		if err != nil {
+			t.Fatalf("expected no errors got %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if len(b) != len(large) {
+			t.Fatalf("expected %d got %d", len(large), len(b))
+		}
+	}
+
+	if counter != 2 {
+		t.Fatalf("expected %d got %d", 2, counter)
+	}
+}
+
+func TestCacheHitsNotLimited(t *testing.T) {
+	tests := []struct {
+		testcase string
+		options  []client.Option
+	}{
+		{
+			testcase: "rate limit",
+			options:  []client.Option{client.WithRateLimit("", 1, 1)},
+		},
+		{
+			testcase: "bulkhead",
+			options:  []client.Option{client.WithMaxConcurrent("", 1), client.WithMaxQueued("", 0)},
+		},
+		{
+			testcase: "circuit breaker",
+			options:  []client.Option{client.WithCircuitBreaker(1, time.Minute)},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/failing" {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					w.Header().Set("Cache-Control", "max-age=60")
+					_, _ = w.Write([]byte("cached"))
+				}))
+			defer server.Close()
+
+			options := append([]client.Option{client.WithCache(client.NewLRUCache(10))}, test.options...)
+			cli, _ := client.New(options...)
+
+			resp, err := cli.Get(context.Background(), server.URL+"/cached")
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			resp.Body.Close()
+
+			// Holding the slot or opening the circuit, the token is already
+			// used up.
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if resp, err := cli.Get(ctx, server.URL+"/failing"); err == nil {
+				defer resp.Body.Close()
+			}
+
+			for i := 0; i < 3; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				resp, err := cli.Get(ctx, server.URL+"/cached")
+				if err != nil {
+					cancel()
+					t.Fatalf("expected no errors got %v", err)
+				}
+				b, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				cancel()
+
+				if string(b) != "cached" {
+					t.Fatalf("expected %s got %s", "cached", string(b))
+				}
+			}
+		})
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := client.NewLRUCache(2)
+	cache.Set("a", &client.CachedResponse{StatusCode: http.StatusOK})
+	cache.Set("b", &client.CachedResponse{StatusCode: http.StatusOK})
+	_, _ = cache.Get("a")
+	cache.Set("c", &client.CachedResponse{StatusCode: http.StatusOK})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatalf("expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Fatalf("expected %s to be cached", key)
+		}
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Fatalf("expected a to be deleted")
+	}
+}
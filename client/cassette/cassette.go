@@ -0,0 +1,309 @@
+// Package cassette provides a http.RoundTripper recording the exchanges with
+// an API to a file, and replaying them offline so that the tests calling it are
+// deterministic.
+//
+// It is used as the transport of the client:
+//
+//	recorder, err := cassette.New("testdata/partner.json", cassette.ModeReplay)
+//	...
+//	cli, err := client.New(client.WithTransport(recorder))
+//
+// The cassette is recorded by running the test once with ModeRecord against
+// the real API, and saved with Recorder.Save.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Version is the version of the cassette file format.
+const Version = 1
+
+var (
+	// ErrInteractionNotFound is returned when replaying a request matching no
+	// recorded interaction.
+	ErrInteractionNotFound = errors.New("Interaction not found")
+	// ErrUnsupportedVersion is returned when loading a cassette whose format
+	// version is not supported.
+	ErrUnsupportedVersion = errors.New("Unsupported cassette version")
+)
+
+// Mode tells whether the recorder records or replays the exchanges.
+type Mode int
+
+const (
+	// ModeReplay answers the requests with the recorded interactions, without
+	// any network access.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests through the real transport and records
+	// the interactions, they are written by Save.
+	ModeRecord
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is a recorded body. It is written as a string when it is valid UTF-8 so
+// that the cassette remains readable, base64 encoded otherwise.
+type Body []byte
+
+// MarshalJSON encodes the body.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON decodes the body.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Recorder is a http.RoundTripper recording or replaying the exchanges of a
+// cassette. It is safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	matchers  []Matcher
+	scrubbers []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// Option configures the recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport the requests are sent through when
+// recording, http.DefaultTransport by default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// MatchOn sets how the requests are matched against the recorded ones, by
+// method and path by default.
+func MatchOn(matchers ...Matcher) Option {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+// New creates a recorder for the cassette file at path, which is loaded when
+// replaying.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matchers:  []Matcher{MatchMethod(), MatchPath()},
+		scrubbers: []func(*Interaction){
+			scrubHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"),
+		},
+		cassette: Cassette{Version: Version},
+	}
+	for _, apply := range opts {
+		apply(r)
+	}
+
+	if mode == ModeReplay {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) load() error {
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(content, &cassette); err != nil {
+		return fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	if cassette.Version != Version {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, cassette.Version)
+	}
+
+	r.cassette = cassette
+	r.replayed = make([]bool, len(cassette.Interactions))
+	return nil
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	content, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// RoundTrip records or replays the exchange.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+
+	resp, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       respBody,
+		},
+	}
+	// The secrets are scrubbed from the recording only, the caller gets the
+	// response as is.
+	for _, scrub := range r.scrubbers {
+		scrub(&interaction)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay answers with the first matching interaction not replayed yet, so
+// that identical requests get the recorded responses in order.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || !r.matches(req, body, interaction.Request) {
+			continue
+		}
+		r.replayed[i] = true
+
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+}
+
+func (r *Recorder) matches(req *http.Request, body []byte, recorded Request) bool {
+	for _, match := range r.matchers {
+		if !match(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
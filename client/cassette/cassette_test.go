@@ -0,0 +1,132 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wrapp/instrumentation/client"
+	"github.com/wrapp/instrumentation/client/cassette"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassettes", "partner.json")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Set-Cookie", "session=secret")
+			_, _ = w.Write([]byte(r.URL.Path + " " + string(body)))
+		}))
+
+	recorder, err := cassette.New(path, cassette.ModeRecord, cassette.ScrubQueryParams("api_key"))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	cli, _ := client.New(client.WithTransport(recorder))
+	for _, body := range []string{"first", "second"} {
+		resp, err := cli.Post(ctx, server.URL+"/users?api_key=secret",
+			client.AuthorizationBearer("secret"),
+			client.Body([]byte(body)),
+		)
+		if err != nil {
+			t.Fatalf("expected no errors got %v", err)
+		}
+		resp.Body.Close()
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	server.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Fatalf("expected the secrets to be scrubbed got %s", content)
+	}
+
+	tests := []struct {
+		testcase string
+		matchers []cassette.Matcher
+		method   string
+		body     string
+		expected string
+		err      error
+	}{
+		{
+			testcase: "replayed in order",
+			method:   http.MethodPost,
+			body:     "anything",
+			expected: "/users first",
+		},
+		{
+			testcase: "matched on body",
+			matchers: []cassette.Matcher{cassette.MatchMethod(), cassette.MatchPath(), cassette.MatchBody()},
+			method:   http.MethodPost,
+			body:     "second",
+			expected: "/users second",
+		},
+		{
+			testcase: "matched on query",
+			matchers: []cassette.Matcher{cassette.MatchPath(), cassette.MatchQuery()},
+			method:   http.MethodPost,
+			expected: "/users first",
+		},
+		{
+			testcase: "not found",
+			method:   http.MethodGet,
+			err:      cassette.ErrInteractionNotFound,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			var opts []cassette.Option
+			if test.matchers != nil {
+				opts = append(opts, cassette.MatchOn(test.matchers...))
+			}
+			recorder, err := cassette.New(path, cassette.ModeReplay, opts...)
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			cli, _ := client.New(client.WithTransport(recorder))
+
+			resp, err := cli.Do(ctx, test.method, server.URL+"/users?api_key=other",
+				client.Body([]byte(test.body)))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != test.expected {
+				t.Fatalf("expected %s got %s", test.expected, body)
+			}
+		})
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partner.json")
+	if err := os.WriteFile(path, []byte(`{"version": 0, "interactions": []}`), 0o644); err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+
+	_, err := cassette.New(path, cassette.ModeReplay)
+	if !errors.Is(err, cassette.ErrUnsupportedVersion) {
+		t.Fatalf("expected %v got %v", cassette.ErrUnsupportedVersion, err)
+	}
+}
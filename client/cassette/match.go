@@ -0,0 +1,71 @@
+package cassette
+
+import (
+	"bytes"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// Matcher tells whether a request, along with its body, matches a recorded
+// request.
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+// MatchMethod matches the requests with the same method.
+func MatchMethod() Matcher {
+	return func(req *http.Request, _ []byte, recorded Request) bool {
+		return req.Method == recorded.Method
+	}
+}
+
+// MatchPath matches the requests with the same URL path, whatever their host.
+func MatchPath() Matcher {
+	return func(req *http.Request, _ []byte, recorded Request) bool {
+		u, err := neturl.Parse(recorded.URL)
+		return err == nil && u.Path == req.URL.Path
+	}
+}
+
+// MatchQuery matches the requests with the same query parameters, whatever
+// their order. The scrubbed parameters are ignored.
+func MatchQuery() Matcher {
+	return func(req *http.Request, _ []byte, recorded Request) bool {
+		u, err := neturl.Parse(recorded.URL)
+		if err != nil {
+			return false
+		}
+		got, expected := req.URL.Query(), u.Query()
+		if len(got) != len(expected) {
+			return false
+		}
+		for key, values := range expected {
+			if len(values) == 1 && values[0] == scrubbed {
+				continue
+			}
+			if strings.Join(values, "&") != strings.Join(got[key], "&") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchBody matches the requests with the same body.
+func MatchBody() Matcher {
+	return func(_ *http.Request, body []byte, recorded Request) bool {
+		return bytes.Equal(body, recorded.Body)
+	}
+}
+
+// MatchHeaders matches the requests with the same values for the given
+// headers. Scrubbed headers must not be matched on as their values are lost.
+func MatchHeaders(names ...string) Matcher {
+	return func(req *http.Request, _ []byte, recorded Request) bool {
+		for _, name := range names {
+			if req.Header.Get(name) != recorded.Header.Get(name) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,55 @@
+package cassette
+
+import (
+	"net/http"
+	neturl "net/url"
+)
+
+// scrubbed replaces the secrets in the cassette.
+const scrubbed = "REDACTED"
+
+// ScrubHeaders scrubs the given request and response headers before the
+// interactions are written, on top of Authorization, Proxy-Authorization,
+// Cookie and Set-Cookie.
+func ScrubHeaders(names ...string) Option {
+	return Scrub(scrubHeaders(names...))
+}
+
+// ScrubQueryParams scrubs the given query parameters of the request URLs
+// before the interactions are written.
+func ScrubQueryParams(names ...string) Option {
+	return Scrub(func(interaction *Interaction) {
+		u, err := neturl.Parse(interaction.Request.URL)
+		if err != nil || u.RawQuery == "" {
+			return
+		}
+		query := u.Query()
+		for _, name := range names {
+			if _, ok := query[name]; ok {
+				query.Set(name, scrubbed)
+			}
+		}
+		u.RawQuery = query.Encode()
+		interaction.Request.URL = u.String()
+	})
+}
+
+// Scrub adds a function removing the secrets of an interaction, eg. in its
+// bodies, before it is written.
+func Scrub(scrub func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrub)
+	}
+}
+
+func scrubHeaders(names ...string) func(*Interaction) {
+	return func(interaction *Interaction) {
+		for _, header := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+			for _, name := range names {
+				if header.Get(name) != "" {
+					header.Set(name, scrubbed)
+				}
+			}
+		}
+	}
+}
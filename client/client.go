@@ -5,40 +5,85 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"sync/atomic"
 	"time"
 
-	"github.com/wrapp/instrumentation/awstraceid"
-	"github.com/wrapp/instrumentation/requestid"
 	"go.opencensus.io/plugin/ochttp"
 )
 
 var (
 	// ErrTimeout is an error raised when a timeout occurs
 	ErrTimeout = errors.New("Timeout")
+	// ErrAttemptTimeout is an error raised when the last attempt of a request
+	// timed out while the overall deadline was not exhausted yet.
+	ErrAttemptTimeout = errors.New("Attempt timeout")
 )
 
+// timeoutError is raised when the request times out or when its parent context
+// is done. It matches ErrTimeout and wraps the error of the context, so that a
+// cancellation (context.Canceled) can be told apart from a deadline
+// (context.DeadlineExceeded).
+type timeoutError struct {
+	cause error
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTimeout, e.cause)
+}
+
+func (e timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.cause
+}
+
 // Client is an instrumented client.
 type Client interface {
 	Get(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
 	Post(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
 	Put(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
 	Delete(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
+	Patch(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
+	Head(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
+	Options(ctx context.Context, url string, funcs ...RequestOption) (Response, error)
+	// Do sends a request with an arbitrary method through the same pipeline as
+	// the other verbs (retries, timeouts, fail managers...).
+	Do(ctx context.Context, method, url string, funcs ...RequestOption) (Response, error)
 }
 
 type client struct {
 	client         http.Client
 	spanNameFormat string
 	serviceName    string
+	retryPolicy    RetryPolicy
+	breakers       *circuitBreakers
+	baseURL        *neturl.URL
+	defaultOptions []RequestOption
+	pool           *connectionPool
+	interceptors   []Interceptor
+
+	withoutDefaultInterceptors bool
+
+	flights   *flights
+	limiters  *rateLimiters
+	bulkheads *bulkheads
 }
 
+// Option is a function that configures the client.
+type Option func(*client) error
+
 // New creates a new instrumented client
-func New(funcs ...func(*client) error) (Client, error) {
+func New(funcs ...Option) (Client, error) {
 	cli := client{
 		serviceName:    os.Getenv("SERVICE_NAME"),
 		spanNameFormat: fmt.Sprintf("from %s", os.Getenv("SERVICE_NAME")),
+		flights:        &flights{},
+		bulkheads:      &bulkheads{},
 	}
 	for _, apply := range funcs {
 		if err := apply(&cli); err != nil {
@@ -46,6 +91,25 @@ func New(funcs ...func(*client) error) (Client, error) {
 		}
 	}
 
+	if !cli.withoutDefaultInterceptors {
+		cli.interceptors = append([]Interceptor{
+			RequestIDInterceptor(),
+			AWSTraceIDInterceptor(),
+			UserAgentInterceptor(cli.serviceName),
+		}, cli.interceptors...)
+	}
+
+	// The transport is built once so that the connections are pooled across
+	// the requests.
+	base := cli.client.Transport
+	if base == nil {
+		base = cli.pool.transport()
+	}
+	cli.client.Transport = &ochttp.Transport{
+		Base:           base,
+		FormatSpanName: func(r *http.Request) string { return cli.spanName(r.Context(), r.Method) },
+	}
+
 	return cli, nil
 }
 
@@ -71,49 +135,158 @@ type cancelableBody struct {
 }
 
 func (b cancelableBody) Close() error {
-	if err := b.ReadCloser.Close(); err != nil {
-		return err
-	}
-	b.cancel()
-	return nil
+	defer b.cancel()
+	return b.ReadCloser.Close()
 }
 
-func (c client) try(ctx context.Context, request Request, cancelFunc context.CancelFunc) (Response, error) {
-	req, err := http.NewRequest(request.method, request.url, request.body)
+// try sends a single attempt of the request. When a FailManager fails, the
+// response is returned drained along with a snippet of its body. The errors
+// raised before the request is sent are wrapped in a notSentError.
+func (c client) try(ctx context.Context, request Request) (*http.Response, []byte, error) {
+	var body io.Reader
+	if request.getBody != nil {
+		var err error
+		if body, err = request.getBody(); err != nil {
+			return nil, nil, notSentError{err}
+		}
+		// A streamed body is closed whatever happens so that its writer
+		// never blocks, even when the request is not sent.
+		if closer, ok := body.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	if body != nil && request.contentEncoding != "" {
+		var err error
+		if body, err = compress(ctx, request.contentEncoding, body); err != nil {
+			return nil, nil, notSentError{err}
+		}
+	}
+
+	req, err := http.NewRequest(request.method, request.url, body)
 	if err != nil {
-		return Response{}, err
+		return nil, nil, notSentError{err}
 	}
 
 	for k, v := range request.headers {
 		req.Header.Set(k, v)
 	}
+	if body != nil && request.contentEncoding != "" {
+		req.Header.Set("Content-Encoding", request.contentEncoding)
+	}
 
-	req = req.WithContext(ctx)
-	c.client.Transport = &ochttp.Transport{
-		FormatSpanName: func(*http.Request) string { return c.spanNameFormat },
+	var idle *idleBody
+	if request.readIdleTimeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		idle = &idleBody{timeout: *request.readIdleTimeout, cancel: cancel}
 	}
+	req = req.WithContext(withRoute(ctx, request.route))
 
 	if request.host != nil {
 		req.Host = *request.host
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := chain(c.guard(), c.interceptors, request.interceptors).Do(req)
+	if body != nil && req.ContentLength >= 0 {
+		recordRequestBytes(ctx, request, req.URL.Host, req.ContentLength)
+	}
 	if err != nil {
-		return Response{}, err
+		idle.release()
+		return nil, nil, err
+	}
+	// The bytes are counted as received, before being decoded.
+	received := &countingReader{r: resp.Body}
+	resp.Body = &releaseOnClose{
+		ReadCloser: limitedBody{received, resp.Body},
+		release: func() {
+			recordResponseBytes(ctx, request, req.URL.Host, resp.StatusCode, received.n)
+		},
+	}
+
+	// Decoded first so that the size limit applies to the decoded body.
+	if len(request.acceptEncodings) > 0 {
+		decompress(ctx, resp)
+	}
+
+	if request.maxResponseBytes != nil {
+		if resp.ContentLength > *request.maxResponseBytes {
+			// Not drained on purpose, it is too large to be worth it.
+			resp.Body.Close()
+			idle.release()
+			return resp, nil, ErrResponseTooLarge
+		}
+		resp.Body = newLimitedBody(resp.Body, *request.maxResponseBytes)
+	}
+
+	if idle != nil {
+		resp.Body = idle.wrap(resp.Body)
 	}
 
 	for _, fm := range request.failManagers {
 		if err := fm.Check(resp); err != nil {
-			_, _ = io.Copy(io.Discard, resp.Body)
-			resp.Body.Close()
-			return Response{}, err
+			snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+			drain(resp)
+			return resp, snippet, err
 		}
 	}
 
-	return Response{Body: cancelableBody{
-		resp.Body,
-		cancelFunc,
-	}, StatusCode: resp.StatusCode}, nil
+	return resp, nil, nil
+}
+
+// guard sends the request through the circuit breaker, the rate limiter and the
+// bulkhead of its host. It comes after the interceptors so that only the
+// requests actually sent upstream (eg. not the cache hits) go through them.
+func (c client) guard() Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		ctx, host := req.Context(), req.URL.Host
+		if err := c.breakers.allow(ctx, host); err != nil {
+			recordCircuitOpen(ctx, host)
+			return nil, err
+		}
+
+		if err := c.limiters.wait(ctx, req.URL); err != nil {
+			c.breakers.record(ctx, host, Attempt{Err: notSentError{err}})
+			return nil, notSentError{err}
+		}
+
+		release, err := c.bulkheads.acquire(ctx, req.URL)
+		if err != nil {
+			c.breakers.record(ctx, host, Attempt{Err: notSentError{err}})
+			return nil, notSentError{err}
+		}
+
+		resp, err := c.client.Do(req)
+		c.breakers.record(ctx, host, Attempt{Response: resp, Err: err})
+		if err != nil {
+			release()
+			return nil, err
+		}
+		resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+// notSentError is the failure of an attempt that was not sent (eg. rejected by
+// the rate limiter or the bulkhead), which says nothing about the health of the
+// host.
+type notSentError struct {
+	cause error
+}
+
+func (e notSentError) Error() string {
+	return e.cause.Error()
+}
+
+func (e notSentError) Unwrap() error {
+	return e.cause
+}
+
+// drain consumes and closes the body of a response that won't be returned so
+// that the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
 }
 
 func noop() {
@@ -121,35 +294,50 @@ func noop() {
 }
 
 func (c client) do(ctx context.Context, url, method string, funcs ...RequestOption) (Response, error) {
+	start := time.Now()
+
+	url, err := c.resolve(url)
+	if err != nil {
+		return Response{}, err
+	}
+
 	req := Request{
 		url:    url,
 		method: method,
 	}
+	fail := func(err error, attempts uint, resp *http.Response, snippet []byte) (Response, error) {
+		return Response{}, newRequestError(req, err, attempts, time.Since(start), resp, snippet)
+	}
 
-	// Applying "battery-included" options.
-	for _, included := range []RequestOption{
-		Header("X-Request-ID", requestid.Get(ctx)),
-		Header(awstraceid.AWSTraceIDHeader, awstraceid.Get(ctx)),
-		UserAgent(c.serviceName),
-	} {
-		if err := included(&req); err != nil {
-			return Response{}, err
+	// Applying the client's default options, then the "on-demand" ones so
+	// that they take precedence.
+	req.defaults = true
+	for _, apply := range c.defaultOptions {
+		if err := apply(&req); err != nil {
+			return fail(err, 0, nil, nil)
 		}
 	}
-
-	// Applying the "on-demand" options
+	req.defaults = false
 	for _, apply := range funcs {
 		if err := apply(&req); err != nil {
-			return Response{}, err
+			return fail(err, 0, nil, nil)
 		}
 	}
+	if err := req.buildURL(); err != nil {
+		return fail(err, 0, nil, nil)
+	}
 
-	type result struct {
-		resp Response
-		err  error
+	if req.coalesce != nil {
+		return c.coalesce(ctx, req, start)
 	}
+	return c.execute(ctx, req, start)
+}
 
-	ch := make(chan result)
+// execute sends the request, retrying it according to its policy.
+func (c client) execute(ctx context.Context, req Request, start time.Time) (Response, error) {
+	fail := func(err error, attempts uint, resp *http.Response, snippet []byte) (Response, error) {
+		return Response{}, newRequestError(req, err, attempts, time.Since(start), resp, snippet)
+	}
 
 	cancelableCtx := ctx
 	cancel := noop
@@ -157,30 +345,182 @@ func (c client) do(ctx context.Context, url, method string, funcs ...RequestOpti
 		cancelableCtx, cancel = context.WithTimeout(ctx, *req.timeout)
 	}
 
-	go func(tryCount uint) {
-		for {
-			resp, err := c.try(cancelableCtx, req, cancel)
-			if err != nil && req.maxRetry != nil && tryCount < *req.maxRetry {
-				if req.backoffRetry != nil {
-					backoff := time.Duration(math.Pow(2, float64(tryCount))-1) * *req.backoffRetry
-					<-time.After(backoff)
-				}
-				tryCount++
-				continue
+	policy := req.retryPolicy
+	if policy == nil {
+		policy = c.retryPolicy
+	}
+	if policy == nil {
+		policy = retryCount{}
+	}
+
+	call := &call{
+		req:    req,
+		policy: policy,
+		ctx:    cancelableCtx,
+		cancel: cancel,
+	}
+	if u, err := neturl.Parse(req.url); err == nil {
+		call.host = u.Host
+	}
+
+	ch := make(chan result)
+	go c.run(call, ch)
+
+	select {
+	case <-cancelableCtx.Done():
+		cancel()
+		recordTimeout(ctx, call)
+		recordRequest(ctx, call, 0, time.Since(start))
+		return fail(timeoutError{cause: cancelableCtx.Err()}, call.attemptCount(), nil, nil)
+	case res := <-ch:
+		if res.err != nil {
+			cancel()
+			statusCode := 0
+			if res.failed != nil {
+				statusCode = res.failed.StatusCode
+			}
+			recordRequest(ctx, call, statusCode, time.Since(start))
+			return fail(res.err, res.attempts, res.failed, res.snippet)
+		}
+		recordRequest(ctx, call, res.resp.StatusCode, time.Since(start))
+		return res.resp, nil
+	}
+}
+
+// call holds the state of a request being sent.
+type call struct {
+	req    Request
+	policy RetryPolicy
+	host   string
+	// ctx is bounded by the timeout of the request, cancel releases it.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// attempts is the number of attempts sent so far, it is accessed atomically.
+	attempts uint32
+}
+
+func (c *call) attemptCount() uint {
+	return uint(atomic.LoadUint32(&c.attempts))
+}
+
+// result is the outcome of a call.
+type result struct {
+	resp     Response
+	err      error
+	attempts uint
+	failed   *http.Response
+	snippet  []byte
+}
+
+// run sends the attempts of the call until one succeeds or the retry policy
+// gives up, and hands the result over ch. It stops as soon as the context of the
+// call is done, in which case nobody is waiting for the result anymore.
+func (c client) run(call *call, ch chan<- result) {
+	send := func(res result) {
+		select {
+		case ch <- res:
+		case <-call.ctx.Done():
+			// The response came in too late, it must still be closed to
+			// release the connection.
+			if res.resp.Body.ReadCloser != nil {
+				res.resp.Body.Close()
 			}
+		}
+	}
+
+	for number := uint(1); ; number++ {
+		atomic.StoreUint32(&call.attempts, uint32(number))
 
-			ch <- result{resp: resp, err: err}
+		// Each attempt gets its own deadline so that a slow attempt does not
+		// eat the whole budget of the request.
+		attemptCtx, attemptCancel := call.ctx, context.CancelFunc(noop)
+		if call.req.attemptTimeout != nil {
+			attemptCtx, attemptCancel = context.WithTimeout(call.ctx, *call.req.attemptTimeout)
+		}
+		attemptCtx = withAttempt(attemptCtx, number)
+
+		resp, snippet, err := c.send(attemptCtx, call.req)
+		if errors.Is(err, ErrCircuitOpen) {
+			attemptCancel()
+			send(result{err: err, attempts: number - 1})
 			return
 		}
-	}(1)
+		if resp == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) &&
+			call.ctx.Err() == nil {
+			err = fmt.Errorf("%w: %v", ErrAttemptTimeout, err)
+			recordTimeout(call.ctx, call)
+		}
+		attempt := Attempt{
+			Method:   call.req.method,
+			URL:      call.req.url,
+			Number:   number,
+			Response: resp,
+			Err:      err,
+		}
+		retry := call.policy.ShouldRetry(attempt)
+		if retry && call.req.streamed {
+			// The body was consumed by this attempt, the request fails
+			// rather than being sent again.
+			if err == nil {
+				snippet, _ = io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+				drain(resp)
+			}
+			err = notReplayableError{cause: err}
+			retry = false
+		}
+		var delay time.Duration
+		if retry {
+			delay = call.policy.Delay(attempt)
+			if resp != nil {
+				if after := retryAfter(resp); after > delay {
+					delay = after
+					// The server won't be available before the deadline,
+					// the outcome of this attempt is returned rather than
+					// waiting for a timeout.
+					if deadline, ok := call.ctx.Deadline(); ok && time.Until(deadline) < delay {
+						retry = false
+					}
+				}
+			}
+		}
+		if retry {
+			if err == nil {
+				drain(resp)
+			}
+			attemptCancel()
+			if !sleep(call.ctx, delay) {
+				return
+			}
+			continue
+		}
 
-	for {
-		select {
-		case <-cancelableCtx.Done():
-			return Response{}, ErrTimeout
-		case res := <-ch:
-			return res.resp, res.err
+		if err != nil {
+			attemptCancel()
+			send(result{err: err, attempts: number, failed: resp, snippet: snippet})
+			return
 		}
+		send(result{resp: Response{Body: cancelableBody{
+			resp.Body,
+			func() {
+				attemptCancel()
+				call.cancel()
+			},
+		}, StatusCode: resp.StatusCode}})
+		return
+	}
+}
+
+// sleep waits for the given delay, it returns false if the context is done
+// before.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
@@ -199,3 +539,19 @@ func (c client) Put(ctx context.Context, url string, funcs ...RequestOption) (Re
 func (c client) Delete(ctx context.Context, url string, funcs ...RequestOption) (Response, error) {
 	return c.do(ctx, url, http.MethodDelete, funcs...)
 }
+
+func (c client) Patch(ctx context.Context, url string, funcs ...RequestOption) (Response, error) {
+	return c.do(ctx, url, http.MethodPatch, funcs...)
+}
+
+func (c client) Head(ctx context.Context, url string, funcs ...RequestOption) (Response, error) {
+	return c.do(ctx, url, http.MethodHead, funcs...)
+}
+
+func (c client) Options(ctx context.Context, url string, funcs ...RequestOption) (Response, error) {
+	return c.do(ctx, url, http.MethodOptions, funcs...)
+}
+
+func (c client) Do(ctx context.Context, method, url string, funcs ...RequestOption) (Response, error) {
+	return c.do(ctx, url, method, funcs...)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -175,6 +176,9 @@ func TestTimeout(t *testing.T) {
 	if !errors.Is(err, client.ErrTimeout) {
 		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
 	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v got %v", context.DeadlineExceeded, err)
+	}
 }
 
 func TestRetries(t *testing.T) {
@@ -244,6 +248,69 @@ func TestCancelable(t *testing.T) {
 	if !errors.Is(err, client.ErrTimeout) {
 		t.Fatalf("expected %v got %v\n", client.ErrTimeout, err)
 	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v got %v\n", context.Canceled, err)
+	}
+}
+
+type closeNotifier struct {
+	io.Reader
+	closed chan struct{}
+}
+
+func (b closeNotifier) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestLateResponseIsClosed(t *testing.T) {
+	ctx := context.Background()
+
+	body := closeNotifier{Reader: bytes.NewReader(nil), closed: make(chan struct{})}
+	cli, _ := client.New(client.WithTransport(roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			// A transport ignoring the cancellation of the request.
+			<-time.After(50 * time.Millisecond)
+			return &http.Response{StatusCode: http.StatusOK, Body: body, Request: req}, nil
+		})))
+
+	_, err := cli.Get(ctx, "http://example.invalid", client.Timeout(10*time.Millisecond))
+	if !errors.Is(err, client.ErrTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
+	}
+
+	select {
+	case <-body.closed:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the late response to be closed")
+	}
+}
+
+func TestTimeoutStopsBackoff(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	_, err := cli.Get(ctx, server.URL,
+		client.FailOn(client.StatusChecker(errors.New("oops"), http.StatusInternalServerError)),
+		client.RetryWithBackoff(3, 100*time.Millisecond),
+		client.Timeout(20*time.Millisecond),
+	)
+	if !errors.Is(err, client.ErrTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
+	}
+
+	<-time.After(200 * time.Millisecond)
+	if got := atomic.LoadInt32(&counter); got != 1 {
+		t.Fatalf("expected the backoff to be interrupted, got %d requests", got)
+	}
 }
 
 func TestRetryWithBackoff(t *testing.T) {
@@ -342,3 +409,205 @@ func TestAuthorizationBearer(t *testing.T) {
 		t.Fatalf("got an unexpected error %v", err)
 	}
 }
+
+func TestMethods(t *testing.T) {
+	ctx := context.Background()
+
+	cli, _ := client.New()
+
+	tests := []struct {
+		method string
+		call   func(string) (client.Response, error)
+	}{
+		{
+			method: http.MethodPatch,
+			call:   func(url string) (client.Response, error) { return cli.Patch(ctx, url) },
+		},
+		{
+			method: http.MethodHead,
+			call:   func(url string) (client.Response, error) { return cli.Head(ctx, url) },
+		},
+		{
+			method: http.MethodOptions,
+			call:   func(url string) (client.Response, error) { return cli.Options(ctx, url) },
+		},
+		{
+			method: "PROPFIND",
+			call:   func(url string) (client.Response, error) { return cli.Do(ctx, "PROPFIND", url) },
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.method, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != test.method {
+						t.Fatalf("expected method %s got %s", test.method, r.Method)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			defer server.Close()
+
+			resp, err := test.call(server.URL)
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("expected status %d got %d", http.StatusNoContent, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	ctx := context.Background()
+	buffer := []byte(`{"id": "1337", "msg": "yo"}`)
+
+	counter := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			b, _ := io.ReadAll(r.Body)
+			if !bytes.Equal(b, buffer) {
+				t.Fatalf("attempt %d: expected %s got %s", counter, string(buffer), string(b))
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	_, _ = cli.Post(ctx, server.URL,
+		client.Body(buffer),
+		client.FailOn(client.StatusChecker(errors.New("oops"), http.StatusInternalServerError)),
+		client.Retry(3),
+	)
+	if counter != 3 {
+		t.Fatalf("expected %d got %d", 3, counter)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	ctx := context.Background()
+
+	counter := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			if counter == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	start := time.Now()
+	cli, _ := client.New()
+	resp, err := cli.Get(ctx, server.URL,
+		client.FailOn(client.StatusChecker(errors.New("oops"), http.StatusTooManyRequests)),
+		client.RetryWithBackoff(2, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if duration := time.Since(start); duration < time.Second {
+		t.Fatalf("expected to wait at least %v got %v", time.Second, duration)
+	}
+}
+
+func TestRetryAfterPastDeadline(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	start := time.Now()
+	cli, _ := client.New()
+	_, err := cli.Get(ctx, server.URL,
+		client.FailOn(client.StatusChecker(someError, http.StatusServiceUnavailable)),
+		client.Retry(2),
+		client.Timeout(time.Second),
+	)
+	if !errors.Is(err, someError) {
+		t.Fatalf("expected %v got %v", someError, err)
+	}
+	if duration := time.Since(start); duration >= time.Second {
+		t.Fatalf("expected to give up right away got %v", duration)
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase      string
+		slowAttempts  int
+		timeout       time.Duration
+		expectedError error
+	}{
+		{
+			testcase:     "should retry after a slow attempt",
+			slowAttempts: 1,
+			timeout:      time.Second,
+		},
+		{
+			testcase:      "should fail with an attempt timeout if every attempt is slow",
+			slowAttempts:  3,
+			timeout:       time.Second,
+			expectedError: client.ErrAttemptTimeout,
+		},
+		{
+			testcase:      "should fail with a timeout if the overall deadline is exhausted",
+			slowAttempts:  3,
+			timeout:       30 * time.Millisecond,
+			expectedError: client.ErrTimeout,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			counter := int32(0)
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if int(atomic.AddInt32(&counter, 1)) <= test.slowAttempts {
+						<-time.After(100 * time.Millisecond)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			resp, err := cli.Get(ctx, server.URL,
+				client.Timeout(test.timeout),
+				client.AttemptTimeout(20*time.Millisecond),
+				client.Retry(2),
+			)
+			if test.expectedError != nil {
+				if !errors.Is(err, test.expectedError) {
+					t.Fatalf("expected %v got %v", test.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("expected status %d got %d", http.StatusNoContent, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package clienttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wrapp/instrumentation/awstraceid"
+	"github.com/wrapp/instrumentation/requestid"
+)
+
+// AssertDefaultHeaders fails the test unless the call carries the headers the
+// client adds by default: the X-Request-ID and X-Amzn-Trace-Id of the context
+// the request was sent with, and a User-Agent.
+func AssertDefaultHeaders(t testing.TB, ctx context.Context, call Call) {
+	t.Helper()
+
+	headers := []struct {
+		name     string
+		expected string
+	}{
+		{"X-Request-ID", requestid.Get(ctx)},
+		{awstraceid.AWSTraceIDHeader, awstraceid.Get(ctx)},
+	}
+	for _, header := range headers {
+		if header.expected == "" {
+			t.Errorf("expected %s in the context of the request", header.name)
+			continue
+		}
+		if got := call.Header.Get(header.name); got != header.expected {
+			t.Errorf("expected %s %q got %q", header.name, header.expected, got)
+		}
+	}
+
+	if call.Header.Get("User-Agent") == "" {
+		t.Errorf("expected a User-Agent header got none")
+	}
+}
@@ -0,0 +1,225 @@
+// Package clienttest provides a programmable fake to test the code depending
+// on client.Client without spinning up a server.
+//
+// The fake is a transport: the requests go through a real client so that its
+// options, retries and interceptors are exercised as in production.
+//
+//	fake := clienttest.NewFake()
+//	fake.On(http.MethodGet, "/users/*").RespondJSON(http.StatusOK, user)
+//	cli, _ := fake.Client()
+//	...
+//	fake.AssertExpectations(t)
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+// ErrUnexpectedRequest is returned for the requests matching no expectation.
+var ErrUnexpectedRequest = errors.New("Unexpected request")
+
+// Call is a request received by the fake.
+type Call struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Fake is a http.RoundTripper answering the requests according to its
+// expectations and recording them. It is safe for concurrent use.
+type Fake struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+// NewFake creates a fake without any expectation.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Client creates a client sending its requests to the fake.
+func (f *Fake) Client(opts ...client.Option) (client.Client, error) {
+	return client.New(append(opts, client.WithTransport(f))...)
+}
+
+// On adds an expectation for the requests with the given method ("" matching
+// any method) whose URL path matches pattern, following the path.Match
+// syntax (eg. "/users/*"). The expectations are looked up in the order they
+// were added, skipping the exhausted ones. By default, the expectation answers
+// an empty 200 response.
+func (f *Fake) On(method, pattern string) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := &Expectation{
+		method:  method,
+		pattern: pattern,
+		status:  http.StatusOK,
+		header:  make(http.Header),
+	}
+	f.expectations = append(f.expectations, e)
+	return e
+}
+
+// Calls returns the requests received so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// AssertExpectations fails the test when an expectation limited with Times
+// was not matched as many times.
+func (f *Fake) AssertExpectations(t testing.TB) {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.expectations {
+		if e.times > 0 && e.matched != e.times {
+			t.Errorf("expected %d calls to %s %s got %d", e.times, e.method, e.pattern, e.matched)
+		}
+	}
+}
+
+// RoundTrip records the request and answers it with the first matching
+// expectation.
+func (f *Fake) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := Call{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		call.Body = body
+	}
+
+	e := f.match(call, req.URL.Path)
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL)
+	}
+
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}, nil
+}
+
+func (f *Fake) match(call Call, urlPath string) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, call)
+	for _, e := range f.expectations {
+		if e.times > 0 && e.matched >= e.times {
+			continue
+		}
+		if e.method != "" && e.method != call.Method {
+			continue
+		}
+		if ok, _ := path.Match(e.pattern, urlPath); !ok {
+			continue
+		}
+		e.matched++
+		return e
+	}
+	return nil
+}
+
+// Expectation describes how the fake answers the requests it matches. It is
+// meant to be configured before the requests are sent.
+type Expectation struct {
+	method  string
+	pattern string
+	status  int
+	header  http.Header
+	body    []byte
+	err     error
+	delay   time.Duration
+	times   int
+	matched int
+}
+
+// Respond answers with the given status and body.
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	e.status = status
+	e.body = []byte(body)
+	return e
+}
+
+// RespondJSON answers with the given status and v encoded in JSON, along with
+// the matching Content-Type. It panics if v can't be encoded.
+func (e *Expectation) RespondJSON(status int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("clienttest: failed to marshal json body: %v", err))
+	}
+	e.status = status
+	e.body = body
+	return e.WithHeader("Content-Type", "application/json")
+}
+
+// WithHeader adds a header to the response.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// Fail answers with the given transport error instead of a response.
+func (e *Expectation) Fail(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Delay waits before answering, unless the request is cancelled before.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times limits the number of requests matched by the expectation, which is
+// unlimited by default.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
@@ -0,0 +1,134 @@
+package clienttest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/awstraceid"
+	"github.com/wrapp/instrumentation/client"
+	"github.com/wrapp/instrumentation/client/clienttest"
+	"github.com/wrapp/instrumentation/requestid"
+)
+
+func TestFake(t *testing.T) {
+	someError := errors.New("some error")
+
+	tests := []struct {
+		testcase       string
+		expect         func(*clienttest.Fake)
+		options        []client.RequestOption
+		expectedStatus int
+		expectedBody   string
+		err            error
+	}{
+		{
+			testcase: "canned response",
+			expect: func(f *clienttest.Fake) {
+				f.On(http.MethodGet, "/users/*").Respond(http.StatusCreated, "hello")
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "hello",
+		},
+		{
+			testcase: "json response",
+			expect: func(f *clienttest.Fake) {
+				f.On("", "/users/42").RespondJSON(http.StatusOK, map[string]int{"id": 42})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42}`,
+		},
+		{
+			testcase: "sequence of responses",
+			expect: func(f *clienttest.Fake) {
+				f.On(http.MethodGet, "/users/*").Respond(http.StatusServiceUnavailable, "").Times(1)
+				f.On(http.MethodGet, "/users/*").Respond(http.StatusOK, "retried").Times(1)
+			},
+			options: []client.RequestOption{
+				client.FailOn(client.StatusChecker(someError, http.StatusServiceUnavailable)),
+				client.Retry(2),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retried",
+		},
+		{
+			testcase: "injected error",
+			expect: func(f *clienttest.Fake) {
+				f.On(http.MethodGet, "/users/*").Fail(someError)
+			},
+			err: someError,
+		},
+		{
+			testcase: "injected latency",
+			expect: func(f *clienttest.Fake) {
+				f.On(http.MethodGet, "/users/*").Delay(time.Second)
+			},
+			options: []client.RequestOption{client.Timeout(10 * time.Millisecond)},
+			err:     client.ErrTimeout,
+		},
+		{
+			testcase: "unexpected request",
+			expect: func(f *clienttest.Fake) {
+				f.On(http.MethodPost, "/users/*")
+			},
+			err: clienttest.ErrUnexpectedRequest,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			fake := clienttest.NewFake()
+			test.expect(fake)
+			cli, _ := fake.Client()
+
+			resp, err := cli.Get(context.Background(), "http://users.local/users/42", test.options...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != test.expectedStatus {
+				t.Fatalf("expected %d got %d", test.expectedStatus, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != test.expectedBody {
+				t.Fatalf("expected %s got %s", test.expectedBody, body)
+			}
+			fake.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordedCalls(t *testing.T) {
+	ctx := requestid.Store(context.Background(), "my-request-id")
+	ctx = awstraceid.Store(ctx, "my-aws-trace-id")
+
+	fake := clienttest.NewFake()
+	fake.On(http.MethodPost, "/users").Respond(http.StatusNoContent, "")
+	cli, _ := fake.Client()
+
+	resp, err := cli.Post(ctx, "http://users.local/users",
+		client.UserAgent("my-user-agent"),
+		client.Body([]byte("payload")),
+	)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(calls))
+	}
+	if string(calls[0].Body) != "payload" {
+		t.Fatalf("expected %s got %s", "payload", calls[0].Body)
+	}
+	clienttest.AssertDefaultHeaders(t, ctx, calls[0])
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrCoalesceNotSafe is raised when coalescing is requested on a method other
+// than GET or HEAD, as the requests could differ by their body.
+var ErrCoalesceNotSafe = errors.New("Coalesced requests must be GET or HEAD")
+
+// Coalesce lets a single request go upstream while the identical ones sent
+// concurrently wait for its response, each of them getting its own copy of the
+// body. Requests are identical when they share the method, the url, the
+// credentials (Authorization and Cookie headers) and the values of the given
+// headers. They share the outcome of the request going upstream, failures
+// included, and its body is buffered to be shared. It can only be used on GET
+// and HEAD requests, when given to WithDefaultRequestOptions it only applies to
+// them.
+//
+// Each caller stops waiting when its own context is done, whereas the request
+// going upstream is bounded by the Timeout option and canceled once no caller
+// waits for it anymore.
+func Coalesce(headers ...string) RequestOption {
+	return func(req *Request) error {
+		if req.method != http.MethodGet && req.method != http.MethodHead {
+			if req.defaults {
+				return nil
+			}
+			return ErrCoalesceNotSafe
+		}
+		req.coalesce = &coalesce{headers: headers}
+		return nil
+	}
+}
+
+type coalesce struct {
+	headers []string
+}
+
+type coalescedResponse struct {
+	statusCode int
+	body       []byte
+}
+
+func (c client) coalesce(ctx context.Context, req Request, start time.Time) (Response, error) {
+	key := coalesceKey(req)
+	shared := c.flights.join(ctx, key)
+	defer c.flights.leave(key, shared)
+
+	leader := false
+	ch := c.flights.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+
+		// The shared request must not fail because the caller who happened to
+		// send it gave up, it is bounded by the Timeout of the request and
+		// canceled once no caller waits for it anymore.
+		resp, err := c.execute(shared.ctx, req, start)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, newRequestError(req, err, 1, time.Since(start), nil, nil)
+		}
+		return coalescedResponse{statusCode: resp.StatusCode, body: body}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return Response{}, newRequestError(req, timeoutError{cause: ctx.Err()}, 0, time.Since(start), nil, nil)
+	case res := <-ch:
+		if !leader {
+			trace.FromContext(ctx).Annotate([]trace.Attribute{
+				trace.StringAttribute("url", redactURL(req.url)),
+			}, "request coalesced")
+		}
+		if res.Err != nil {
+			return Response{}, res.Err
+		}
+
+		coalesced := res.Val.(coalescedResponse)
+		return Response{
+			StatusCode: coalesced.statusCode,
+			Body: cancelableBody{
+				io.NopCloser(bytes.NewReader(coalesced.body)),
+				noop,
+			},
+		}, nil
+	}
+}
+
+// flights tracks the callers waiting for each shared request, so that it is
+// canceled and forgotten once they all gave up.
+type flights struct {
+	group   singleflight.Group
+	mu      sync.Mutex
+	waiting map[string]*flight
+}
+
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// join registers a caller waiting for the shared request of the key. The
+// context of the shared request keeps the values of the context of the first
+// caller but neither its deadline nor its cancellation.
+func (f *flights) join(ctx context.Context, key string) *flight {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.waiting == nil {
+		f.waiting = make(map[string]*flight)
+	}
+	shared, ok := f.waiting[key]
+	if !ok {
+		shared = &flight{}
+		shared.ctx, shared.cancel = context.WithCancel(detachedContext{ctx})
+		f.waiting[key] = shared
+	}
+	shared.waiters++
+	return shared
+}
+
+// leave unregisters a caller, canceling the shared request if it was the last
+// one waiting for it.
+func (f *flights) leave(key string, shared *flight) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	shared.waiters--
+	if shared.waiters > 0 {
+		return
+	}
+	shared.cancel()
+	f.group.Forget(key)
+	delete(f.waiting, key)
+}
+
+// detachedContext keeps the values of its parent (eg. the span, the request id)
+// but neither its deadline nor its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+// coalesceKey is the method and the url of the request, along with the values
+// of the given headers and a hash of its credentials so that the responses are
+// never shared between users.
+func coalesceKey(req Request) string {
+	var key strings.Builder
+	key.WriteString(req.method)
+	key.WriteString(" ")
+	key.WriteString(req.url)
+
+	var credentials []string
+	for k, v := range req.headers {
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "Cookie":
+			credentials = append(credentials, http.CanonicalHeaderKey(k)+": "+v)
+		}
+	}
+	if len(credentials) > 0 {
+		sort.Strings(credentials)
+		key.WriteString(" ")
+		key.WriteString(hashCredentials(credentials))
+	}
+
+	for _, header := range req.coalesce.headers {
+		key.WriteString("\n")
+		key.WriteString(http.CanonicalHeaderKey(header))
+		key.WriteString(": ")
+		for k, v := range req.headers {
+			if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(header) {
+				key.WriteString(v)
+			}
+		}
+	}
+	return key.String()
+}
@@ -0,0 +1,261 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestCoalesce(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(100 * time.Millisecond)
+			_, _ = w.Write([]byte("shared"))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := cli.Get(ctx, server.URL, client.Coalesce("Authorization"))
+			if err != nil {
+				t.Errorf("expected no errors got %v", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			b, _ := io.ReadAll(resp.Body)
+			if string(b) != "shared" {
+				t.Errorf("expected %s got %s", "shared", string(b))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&counter); got != 1 {
+		t.Fatalf("expected %d request got %d", 1, got)
+	}
+}
+
+func TestCoalesceHeaders(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	var wg sync.WaitGroup
+	for _, token := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+
+			resp, err := cli.Get(ctx, server.URL,
+				client.AuthorizationBearer(token),
+				client.Coalesce("Authorization"))
+			if err != nil {
+				t.Errorf("expected no errors got %v", err)
+				return
+			}
+			resp.Body.Close()
+		}(token)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&counter); got != 2 {
+		t.Fatalf("expected requests with different headers to not be coalesced, got %d requests", got)
+	}
+}
+
+func TestCoalesceCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(50 * time.Millisecond)
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	var wg sync.WaitGroup
+	for _, token := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+
+			resp, err := cli.Get(ctx, server.URL,
+				client.AuthorizationBearer(token),
+				client.Coalesce())
+			if err != nil {
+				t.Errorf("expected no errors got %v", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			b, _ := io.ReadAll(resp.Body)
+			if expected := "Bearer " + token; string(b) != expected {
+				t.Errorf("expected %s got %s", expected, string(b))
+			}
+		}(token)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&counter); got != 2 {
+		t.Fatalf("expected requests with different credentials to not be coalesced, got %d requests", got)
+	}
+}
+
+func TestCoalesceNotSafe(t *testing.T) {
+	ctx := context.Background()
+
+	cli, _ := client.New()
+	_, err := cli.Post(ctx, "http://localhost", client.Body([]byte("a")), client.Coalesce())
+	if !errors.Is(err, client.ErrCoalesceNotSafe) {
+		t.Fatalf("expected %v got %v", client.ErrCoalesceNotSafe, err)
+	}
+}
+
+func TestDefaultCoalesceNotSafe(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithDefaultRequestOptions(client.Coalesce()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := cli.Post(ctx, server.URL, client.Body([]byte("a")))
+			if err != nil {
+				t.Errorf("expected no errors got %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&counter); got != 2 {
+		t.Fatalf("expected %d requests got %d", 2, got)
+	}
+}
+
+func TestCoalesceLeaderCancelled(t *testing.T) {
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(100 * time.Millisecond)
+			_, _ = w.Write([]byte("shared"))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error)
+	go func() {
+		_, err := cli.Get(leaderCtx, server.URL, client.Coalesce())
+		leaderErr <- err
+	}()
+	// Letting the leader send the shared request.
+	time.Sleep(10 * time.Millisecond)
+
+	resp, err := cli.Get(context.Background(), server.URL, client.Coalesce())
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if string(b) != "shared" {
+		t.Fatalf("expected %s got %s", "shared", string(b))
+	}
+	if err := <-leaderErr; !errors.Is(err, client.ErrTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
+	}
+	if got := atomic.LoadInt32(&counter); got != 1 {
+		t.Fatalf("expected %d request got %d", 1, got)
+	}
+}
+
+func TestCoalesceAbandoned(t *testing.T) {
+	counter := int32(0)
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&counter, 1) > 1 {
+				_, _ = w.Write([]byte("shared"))
+				return
+			}
+			// Hanging until the shared request is canceled.
+			<-r.Context().Done()
+			canceled <- struct{}{}
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := cli.Get(ctx, server.URL, client.Coalesce())
+	if !errors.Is(err, client.ErrTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the abandoned request to be canceled")
+	}
+
+	resp, err := cli.Get(context.Background(), server.URL, client.Coalesce())
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if string(b) != "shared" {
+		t.Fatalf("expected %s got %s", "shared", string(b))
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"go.opencensus.io/trace"
+)
+
+// Content codings supported by the client.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+)
+
+var (
+	// ErrUnsupportedEncoding is raised when a content coding is not supported.
+	ErrUnsupportedEncoding = errors.New("Unsupported encoding")
+)
+
+// CompressBody compresses the payload of the request with the given encoding
+// (gzip or deflate) and sets the Content-Encoding accordingly. The payload is
+// buffered to be compressed.
+func CompressBody(encoding string) RequestOption {
+	return func(req *Request) error {
+		if encoding != EncodingGzip && encoding != EncodingDeflate {
+			return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+		}
+		req.contentEncoding = encoding
+		return nil
+	}
+}
+
+// AcceptEncoding advertises the given encodings (gzip, deflate or zstd) and
+// transparently decodes the response body compressed with any of them.
+func AcceptEncoding(encodings ...string) RequestOption {
+	return func(req *Request) error {
+		for _, encoding := range encodings {
+			switch encoding {
+			case EncodingGzip, EncodingDeflate, EncodingZstd:
+			default:
+				return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+			}
+		}
+		req.acceptEncodings = encodings
+		return Header("Accept-Encoding", strings.Join(encodings, ", "))(req)
+	}
+}
+
+// compress compresses the payload and records its sizes on the span.
+func compress(ctx context.Context, encoding string, body io.Reader) (io.Reader, error) {
+	var buffer bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buffer)
+	case EncodingDeflate:
+		w = zlib.NewWriter(&buffer)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+
+	size, err := io.Copy(w, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress the body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress the body: %w", err)
+	}
+
+	trace.FromContext(ctx).AddAttributes(
+		trace.Int64Attribute("http.request.uncompressed_size", size),
+		trace.Int64Attribute("http.request.compressed_size", int64(buffer.Len())),
+	)
+	return &buffer, nil
+}
+
+// decompress replaces the body of a compressed response by its decoded
+// version. Unknown encodings are left untouched.
+func decompress(ctx context.Context, resp *http.Response) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case EncodingGzip, EncodingDeflate, EncodingZstd:
+	default:
+		return
+	}
+
+	resp.Body = &decodedBody{
+		ctx:        ctx,
+		encoding:   encoding,
+		compressed: &countingReader{r: resp.Body},
+		original:   resp.Body,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodedBody decodes the body on the fly. The decoder is created on the first
+// read, as creating it already reads the body.
+type decodedBody struct {
+	ctx          context.Context
+	encoding     string
+	compressed   *countingReader
+	original     io.Closer
+	decoder      io.ReadCloser
+	uncompressed int64
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.decoder == nil {
+		decoder, err := newDecoder(b.encoding, b.compressed)
+		if err != nil {
+			return 0, err
+		}
+		b.decoder = decoder
+	}
+
+	n, err := b.decoder.Read(p)
+	b.uncompressed += int64(n)
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	if b.decoder != nil {
+		b.decoder.Close()
+	}
+
+	trace.FromContext(b.ctx).AddAttributes(
+		trace.Int64Attribute("http.response.compressed_size", b.compressed.n),
+		trace.Int64Attribute("http.response.uncompressed_size", b.uncompressed),
+	)
+	return b.original.Close()
+}
+
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return zlib.NewReader(r)
+	case EncodingZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestCompressBody(t *testing.T) {
+	ctx := context.Background()
+	buffer := []byte(`{"id": "1337", "msg": "yo"}`)
+
+	tests := []struct {
+		encoding string
+		decode   func(io.Reader) (io.Reader, error)
+	}{
+		{
+			encoding: client.EncodingGzip,
+			decode:   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			encoding: client.EncodingDeflate,
+			decode:   func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("Content-Encoding"); got != test.encoding {
+						t.Fatalf("expected content-encoding %s got %s", test.encoding, got)
+					}
+					decoded, err := test.decode(r.Body)
+					if err != nil {
+						t.Fatalf("unable to decode body, got %v", err)
+					}
+					b, _ := io.ReadAll(decoded)
+					if !bytes.Equal(b, buffer) {
+						t.Fatalf("expected %s got %s", string(buffer), string(b))
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			resp, err := cli.Post(ctx, server.URL, client.Body(buffer), client.CompressBody(test.encoding))
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+		})
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	ctx := context.Background()
+	expected := []byte(`{"id": "42", "msg": "the answer to life the universe and everything"}`)
+
+	tests := []struct {
+		encoding string
+		encode   func(io.Writer) io.WriteCloser
+	}{
+		{
+			encoding: client.EncodingGzip,
+			encode:   func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		},
+		{
+			encoding: client.EncodingDeflate,
+			encode:   func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		},
+		{
+			encoding: client.EncodingZstd,
+			encode: func(w io.Writer) io.WriteCloser {
+				encoder, _ := zstd.NewWriter(w)
+				return encoder
+			},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					expectedAccept := "gzip, deflate, zstd"
+					if got := r.Header.Get("Accept-Encoding"); got != expectedAccept {
+						t.Fatalf("expected accept-encoding %s got %s", expectedAccept, got)
+					}
+					w.Header().Set("Content-Encoding", test.encoding)
+					encoder := test.encode(w)
+					_, _ = encoder.Write(expected)
+					encoder.Close()
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			resp, err := cli.Get(ctx, server.URL, client.AcceptEncoding(
+				client.EncodingGzip, client.EncodingDeflate, client.EncodingZstd))
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("unable to read body, got %v", err)
+			}
+			if !bytes.Equal(b, expected) {
+				t.Fatalf("expected %s got %s", string(expected), string(b))
+			}
+		})
+	}
+}
@@ -4,11 +4,28 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	neturl "net/url"
 	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
 )
 
 const (
 	fieldErrMsg = "Context: '%s' Error:Field validation for '%s' failed. Expected: %s but given: %s"
+
+	// maxBodySnippet is the maximum size of the response body kept in a
+	// RequestError.
+	maxBodySnippet = 512
+)
+
+// redactedQueryParams are the query parameters whose value is redacted from the
+// URL of a RequestError. They are matched case-insensitively, either exactly or
+// as a substring for the ones that can't be ambiguous.
+var (
+	redactedQueryParams       = []string{"key", "sig", "code"}
+	redactedQueryParamsSubstr = []string{"token", "secret", "password", "passwd", "signature", "apikey", "api_key", "auth"}
 )
 
 // FieldError describes error of a field
@@ -69,3 +86,177 @@ func ParseValidationErrors(body []byte, message string) (ValidationErrors, error
 	}
 	return validationErrors, nil
 }
+
+// ProblemError is an RFC 7807 problem details document returned by a server.
+type ProblemError struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions holds the members of the document which are not defined by
+	// the RFC.
+	Extensions map[string]interface{}
+	// Err is the error given to the FailManager.
+	Err error
+}
+
+// problemMembers are the members of a problem details document defined by the
+// RFC.
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// ParseProblem parses a byte array for a problem details document. Its type
+// defaults to "about:blank", as required by the RFC.
+func ParseProblem(body []byte) (ProblemError, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return ProblemError{}, fmt.Errorf("failed to parse problem details: %w", err)
+	}
+
+	var problem ProblemError
+	fields := []interface{}{&problem.Type, &problem.Title, &problem.Status, &problem.Detail, &problem.Instance}
+	for i, name := range problemMembers {
+		raw, ok := members[name]
+		if !ok {
+			continue
+		}
+		// Members with an invalid type are ignored, as required by the RFC.
+		_ = json.Unmarshal(raw, fields[i])
+		delete(members, name)
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+
+	if len(members) > 0 {
+		problem.Extensions = make(map[string]interface{}, len(members))
+		for name, raw := range members {
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err == nil {
+				problem.Extensions[name] = value
+			}
+		}
+	}
+	return problem, nil
+}
+
+func (e ProblemError) Error() string {
+	message := e.Title
+	if message == "" {
+		message = e.Type
+	}
+	if e.Detail != "" {
+		message = fmt.Sprintf("%s: %s", message, e.Detail)
+	}
+	if e.Err == nil {
+		return message
+	}
+	return fmt.Sprintf("%v: %s", e.Err, message)
+}
+
+// Unwrap returns the error given to the FailManager.
+func (e ProblemError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError describes a request that failed, whatever the cause (transport
+// error, timeout, FailManager...). The cause can be retrieved with errors.Is and
+// errors.As.
+type RequestError struct {
+	Method string
+	// URL is the requested url, where the userinfo password and the secrets of
+	// the query are redacted.
+	URL string
+	// Route is the path template of the request, if set with Path.
+	Route string
+	// StatusCode is 0 if the request failed before getting a response.
+	StatusCode int
+	Attempts   uint
+	Elapsed    time.Duration
+	// Body is a truncated snippet of the response body.
+	Body string
+	Err  error
+}
+
+func newRequestError(req Request, err error, attempts uint, elapsed time.Duration,
+	resp *http.Response, snippet []byte) RequestError {
+
+	reqErr := RequestError{
+		Method:   req.method,
+		URL:      redactURL(req.url),
+		Route:    req.route,
+		Attempts: attempts,
+		Elapsed:  elapsed,
+		Body:     string(snippet),
+		Err:      err,
+	}
+	if resp != nil {
+		reqErr.StatusCode = resp.StatusCode
+	}
+	return reqErr
+}
+
+func (e RequestError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s %s: %v", e.Method, e.URL, e.Err)
+	}
+	return fmt.Sprintf("%s %s (status %d): %v", e.Method, e.URL, e.StatusCode, e.Err)
+}
+
+// Unwrap returns the cause of the failure.
+func (e RequestError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalZerologObject renders the error as structured log fields.
+func (e RequestError) MarshalZerologObject(event *zerolog.Event) {
+	event.Str("method", e.Method).
+		Str("url", e.URL).
+		Uint("attempts", e.Attempts).
+		Dur("elapsed", e.Elapsed)
+	if e.Route != "" {
+		event.Str("route", e.Route)
+	}
+	if e.StatusCode != 0 {
+		event.Int("status", e.StatusCode)
+	}
+	if e.Body != "" {
+		event.Str("body", e.Body)
+	}
+}
+
+func redactURL(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if isSecretQueryParam(key) {
+				for i := range query[key] {
+					query[key][i] = "REDACTED"
+				}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+func isSecretQueryParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, param := range redactedQueryParams {
+		if key == param {
+			return true
+		}
+	}
+	for _, param := range redactedQueryParamsSubstr {
+		if strings.Contains(key, param) {
+			return true
+		}
+	}
+	return false
+}
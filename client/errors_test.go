@@ -1,6 +1,11 @@
 package client_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/wrapp/instrumentation/client"
@@ -66,3 +71,50 @@ func TestParseValidationErrorWithoutMessageOverride(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRequestError(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	_, err := cli.Get(ctx, server.URL+"/users?access_token=secret&page=2",
+		client.FailOn(client.StatusChecker(someError, http.StatusInternalServerError)),
+		client.Retry(2),
+	)
+
+	if !errors.Is(err, someError) {
+		t.Fatalf("expected %v got %v", someError, err)
+	}
+
+	var reqErr client.RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected a RequestError got %T", err)
+	}
+
+	expectedURL := server.URL + "/users?access_token=REDACTED&page=2"
+	if reqErr.URL != expectedURL {
+		t.Fatalf("expected url %s got %s", expectedURL, reqErr.URL)
+	}
+	if reqErr.Method != http.MethodGet {
+		t.Fatalf("expected method %s got %s", http.MethodGet, reqErr.Method)
+	}
+	if reqErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status %d got %d", http.StatusInternalServerError, reqErr.StatusCode)
+	}
+	if reqErr.Attempts != 2 {
+		t.Fatalf("expected %d attempts got %d", 2, reqErr.Attempts)
+	}
+	if len(reqErr.Body) != 512 {
+		t.Fatalf("expected the body to be truncated to %d got %d", 512, len(reqErr.Body))
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("expected the secret to be redacted from %s", err.Error())
+	}
+}
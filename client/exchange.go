@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wrapp/instrumentation/logs"
+)
+
+// attemptKey is the context key holding the number of the attempt being sent.
+type attemptKey struct{}
+
+func withAttempt(ctx context.Context, number uint) context.Context {
+	return context.WithValue(ctx, attemptKey{}, number)
+}
+
+// exchangeLogger logs the requests sent and the responses received.
+type exchangeLogger struct {
+	level        zerolog.Level
+	headers      bool
+	maxBodyBytes int
+	// redactedHeaders are canonical header names, redactedFields and
+	// maskedFields lower-cased JSON field names.
+	redactedHeaders map[string]bool
+	redactedFields  map[string]bool
+	maskedFields    map[string]bool
+	output          io.Writer
+}
+
+// LogOption configures the logging of the exchanges.
+type LogOption func(*exchangeLogger)
+
+// LogHeaders adds the headers of the request and of the response to the logs.
+func LogHeaders() LogOption {
+	return func(l *exchangeLogger) {
+		l.headers = true
+	}
+}
+
+// LogBodies adds up to maxBytes of the textual bodies of the request and of the
+// response to the logs. JSON bodies are only logged when they can be parsed, so
+// that their fields are redacted, and the other bodies when they are text/*.
+func LogBodies(maxBytes int) LogOption {
+	return func(l *exchangeLogger) {
+		l.maxBodyBytes = maxBytes
+	}
+}
+
+// RedactHeaders adds headers whose values are replaced by "REDACTED" in the
+// logs, on top of Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+func RedactHeaders(names ...string) LogOption {
+	return func(l *exchangeLogger) {
+		for _, name := range names {
+			l.redactedHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// RedactJSONFields adds JSON fields, at any depth, whose values are replaced by
+// "REDACTED" in the logged bodies, on top of password, secret and token.
+func RedactJSONFields(names ...string) LogOption {
+	return func(l *exchangeLogger) {
+		for _, name := range names {
+			l.redactedFields[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// MaskJSONFields adds JSON fields, at any depth, whose values are masked with
+// logs.MaskSSN in the logged bodies, on top of ssn.
+func MaskJSONFields(names ...string) LogOption {
+	return func(l *exchangeLogger) {
+		for _, name := range names {
+			l.maskedFields[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// LogOutput writes the logs of the exchanges to w rather than to the output of
+// the logs package.
+func LogOutput(w io.Writer) LogOption {
+	return func(l *exchangeLogger) {
+		l.output = w
+	}
+}
+
+// LogExchanges logs each attempt sent by the client at the given level, with
+// its method, URL, status, latency and number. The headers and the bodies can
+// be added with LogHeaders and LogBodies, in which case the secrets are
+// redacted so that it is safe to enable in production.
+//
+// The attempts are logged as seen by the transport, ie. once the interceptors
+// registered before have been applied.
+func LogExchanges(level zerolog.Level, opts ...LogOption) Option {
+	l := &exchangeLogger{
+		level: level,
+		redactedHeaders: map[string]bool{
+			"Authorization":       true,
+			"Proxy-Authorization": true,
+			"Cookie":              true,
+			"Set-Cookie":          true,
+		},
+		redactedFields: map[string]bool{"password": true, "secret": true, "token": true},
+		maskedFields:   map[string]bool{"ssn": true},
+	}
+	for _, apply := range opts {
+		apply(l)
+	}
+	return WithInterceptors(l.intercept)
+}
+
+func (l *exchangeLogger) intercept(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		logger := logs.New(ctx)
+		if l.output != nil {
+			output := logger.Output(l.output)
+			logger = &output
+		}
+		event := logger.WithLevel(l.level)
+		if !event.Enabled() {
+			return next.Do(req)
+		}
+
+		event.Str("method", req.Method).
+			Str("url", redactURL(req.URL.String()))
+		if route, ok := ctx.Value(routeKey{}).(string); ok {
+			event.Str("route", route)
+		}
+		if number, ok := ctx.Value(attemptKey{}).(uint); ok {
+			event.Uint("attempt", number)
+		}
+		if l.headers {
+			event.Dict("request_headers", l.headerFields(req.Header))
+		}
+		if l.maxBodyBytes > 0 && req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				snippet, _ := io.ReadAll(io.LimitReader(body, int64(l.maxBodyBytes)))
+				body.Close()
+				l.body(event, "request_body", req.Header, snippet)
+			}
+		}
+
+		start := time.Now()
+		resp, err := next.Do(req)
+		event.Dur("latency", time.Since(start))
+		if err != nil {
+			event.Err(err).Msg("http exchange")
+			return resp, err
+		}
+
+		event.Int("status", resp.StatusCode)
+		if l.headers {
+			event.Dict("response_headers", l.headerFields(resp.Header))
+		}
+		if l.maxBodyBytes > 0 {
+			// The snippet is put back in front of the body for the caller.
+			snippet, _ := io.ReadAll(io.LimitReader(resp.Body, int64(l.maxBodyBytes)))
+			resp.Body = limitedBody{io.MultiReader(bytes.NewReader(snippet), resp.Body), resp.Body}
+			l.body(event, "response_body", resp.Header, snippet)
+		}
+		event.Msg("http exchange")
+		return resp, nil
+	})
+}
+
+func (l *exchangeLogger) headerFields(header http.Header) *zerolog.Event {
+	dict := zerolog.Dict()
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if l.redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = "REDACTED"
+		}
+		dict.Str(name, value)
+	}
+	return dict
+}
+
+// body adds the snippet of a body to the logs, provided it is textual. JSON
+// bodies which can't be parsed (eg. truncated) are replaced by a placeholder as
+// their fields can't be redacted.
+func (l *exchangeLogger) body(event *zerolog.Event, key string, header http.Header, snippet []byte) {
+	if len(snippet) == 0 {
+		return
+	}
+
+	if header.Get("Content-Encoding") != "" {
+		event.Str(key, "<encoded body>")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(snippet, &v); err != nil {
+			event.Str(key, "<unparsable json>")
+			return
+		}
+		redacted, err := json.Marshal(l.redact(v))
+		if err != nil {
+			return
+		}
+		event.RawJSON(key, redacted)
+	case strings.HasPrefix(mediaType, "text/"):
+		event.Str(key, string(snippet))
+	}
+}
+
+// redact replaces the values of the redacted and masked fields of a decoded
+// JSON value.
+func (l *exchangeLogger) redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			name := strings.ToLower(key)
+			switch {
+			case l.redactedFields[name]:
+				v[key] = "REDACTED"
+			case l.maskedFields[name]:
+				if s, ok := value.(string); ok {
+					v[key] = logs.MaskSSN(s)
+				} else {
+					v[key] = "REDACTED"
+				}
+			default:
+				v[key] = l.redact(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = l.redact(v[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestLogExchanges(t *testing.T) {
+	ctx := context.Background()
+	responseBody := `{"user":{"ssn":"19900101-1234","token":"abc"},"name":"john"}`
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=secret")
+			_, _ = w.Write([]byte(responseBody))
+		}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	cli, _ := client.New(client.LogExchanges(zerolog.InfoLevel,
+		client.LogOutput(&out),
+		client.LogHeaders(),
+		client.LogBodies(512),
+		client.RedactJSONFields("name"),
+	))
+
+	resp, err := cli.Post(ctx, server.URL,
+		client.Path("/users/{id}", "id", "42"),
+		client.AuthorizationBearer("secret"),
+		client.JSONBody(map[string]string{"password": "secret", "ssn": "19900101-1234"}),
+	)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != responseBody {
+		t.Fatalf("expected %s got %s", responseBody, body)
+	}
+
+	var got struct {
+		Method          string            `json:"method"`
+		Route           string            `json:"route"`
+		Attempt         uint              `json:"attempt"`
+		Status          int               `json:"status"`
+		RequestHeaders  map[string]string `json:"request_headers"`
+		ResponseHeaders map[string]string `json:"response_headers"`
+		RequestBody     map[string]string `json:"request_body"`
+		ResponseBody    struct {
+			User map[string]string `json:"user"`
+			Name string            `json:"name"`
+		} `json:"response_body"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+
+	tests := []struct {
+		testcase string
+		expected interface{}
+		got      interface{}
+	}{
+		{"method", http.MethodPost, got.Method},
+		{"route", "/users/{id}", got.Route},
+		{"attempt", uint(1), got.Attempt},
+		{"status", http.StatusOK, got.Status},
+		{"authorization header", "REDACTED", got.RequestHeaders["Authorization"]},
+		{"cookie header", "REDACTED", got.ResponseHeaders["Set-Cookie"]},
+		{"password field", "REDACTED", got.RequestBody["password"]},
+		{"request ssn field", "*********1234", got.RequestBody["ssn"]},
+		{"response ssn field", "*********1234", got.ResponseBody.User["ssn"]},
+		{"token field", "REDACTED", got.ResponseBody.User["token"]},
+		{"custom field", "REDACTED", got.ResponseBody.Name},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			if test.got != test.expected {
+				t.Fatalf("expected %v got %v", test.expected, test.got)
+			}
+		})
+	}
+}
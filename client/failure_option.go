@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 )
 
@@ -12,6 +13,81 @@ type FailManager interface {
 	Check(*http.Response) error
 }
 
+// FailManagerFunc is an adapter to use a function as a FailManager.
+type FailManagerFunc func(*http.Response) error
+
+// Check calls f(resp).
+func (f FailManagerFunc) Check(resp *http.Response) error {
+	return f(resp)
+}
+
+// AnyOf creates a FailManager that fails as soon as one of the given ones
+// fails, with its error.
+func AnyOf(fms ...FailManager) FailManager {
+	return FailManagerFunc(func(resp *http.Response) error {
+		for _, fm := range fms {
+			if err := fm.Check(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AllOf creates a FailManager that fails when all the given ones fail, with the
+// error of the first one.
+func AllOf(fms ...FailManager) FailManager {
+	return FailManagerFunc(func(resp *http.Response) error {
+		var first error
+		for _, fm := range fms {
+			err := fm.Check(resp)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+// Not creates a FailManager that fails with err when the given one does not
+// fail.
+func Not(err error, fm FailManager) FailManager {
+	return FailManagerFunc(func(resp *http.Response) error {
+		if fm.Check(resp) != nil {
+			return nil
+		}
+		return err
+	})
+}
+
+// HasHeader creates a FailManager that fails when the response has the header.
+func HasHeader(err error, key string) FailManager {
+	return FailManagerFunc(func(resp *http.Response) error {
+		if len(resp.Header.Values(key)) == 0 {
+			return nil
+		}
+		return err
+	})
+}
+
+// HeaderChecker creates a FailManager that fails when the header of the
+// response has one of the given values.
+func HeaderChecker(err error, key string, values ...string) FailManager {
+	return FailManagerFunc(func(resp *http.Response) error {
+		for _, got := range resp.Header.Values(key) {
+			for _, value := range values {
+				if got == value {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
 func contains(status int, statusList []int) bool {
 	for _, s := range statusList {
 		if s == status {
@@ -64,6 +140,15 @@ func HasValidationErrors(err error) FailManager {
 	return validationErrorsChecker{raiseErr: err}
 }
 
+// peekBody reads the body of the response, bounded by MaxResponseBytes if any,
+// and reinjects it so that it can still be read by someone else.
+func peekBody(resp *http.Response) ([]byte, error) {
+	original := resp.Body
+	body, err := io.ReadAll(original)
+	resp.Body = limitedBody{io.MultiReader(bytes.NewReader(body), original), original}
+	return body, err
+}
+
 type validationErrorsChecker struct {
 	raiseErr error
 }
@@ -72,12 +157,7 @@ func (checker validationErrorsChecker) Check(resp *http.Response) error {
 	if resp.StatusCode != http.StatusBadRequest {
 		return nil
 	}
-	// Retrieve the body
-	body, err := io.ReadAll(resp.Body)
-	defer func(bdy []byte) {
-		// reinject the body because it has been consumed previously, in case someone wants to use it.
-		resp.Body = io.NopCloser(bytes.NewBuffer(bdy))
-	}(body)
+	body, err := peekBody(resp)
 	if err != nil {
 		return fmt.Errorf("%s: %w", checker.raiseErr.Error(), fmt.Errorf("failed to read body to check for validation errors: %w", err))
 	}
@@ -91,3 +171,34 @@ func (checker validationErrorsChecker) Check(resp *http.Response) error {
 	}
 	return validationErrors
 }
+
+// HasProblemDetails creates a FailManager that fails when the response is an
+// RFC 7807 application/problem+json document, whatever its status. The error
+// returned is a ProblemError wrapping the given error.
+func HasProblemDetails(err error) FailManager {
+	return problemChecker{raiseErr: err}
+}
+
+type problemChecker struct {
+	raiseErr error
+}
+
+func (checker problemChecker) Check(resp *http.Response) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		return nil
+	}
+	body, err := peekBody(resp)
+	if err != nil {
+		return fmt.Errorf("%s: %w", checker.raiseErr.Error(), fmt.Errorf("failed to read body to check for problem details: %w", err))
+	}
+	problem, err := ParseProblem(body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", checker.raiseErr.Error(), fmt.Errorf("failed to parse problem details from response body: %w", err))
+	}
+	if problem.Status == 0 {
+		problem.Status = resp.StatusCode
+	}
+	problem.Err = checker.raiseErr
+	return problem
+}
@@ -0,0 +1,197 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+const problemResponse = `{
+	"type": "https://example.com/probs/out-of-credit",
+	"title": "You do not have enough credit.",
+	"detail": "Your current balance is 30, but that costs 50.",
+	"balance": 30
+}`
+
+func TestFailManagers(t *testing.T) {
+	someError := errors.New("some error")
+	otherError := errors.New("other error")
+
+	tests := []struct {
+		testcase    string
+		status      int
+		header      http.Header
+		body        string
+		failManager client.FailManager
+		err         error
+	}{
+		{
+			testcase: "FailManagerFunc",
+			status:   http.StatusOK,
+			failManager: client.FailManagerFunc(func(resp *http.Response) error {
+				return someError
+			}),
+			err: someError,
+		},
+		{
+			testcase: "AnyOf fails with the first failure",
+			status:   http.StatusNotFound,
+			failManager: client.AnyOf(
+				client.StatusChecker(someError, http.StatusConflict),
+				client.StatusChecker(otherError, http.StatusNotFound),
+				client.StatusChecker(someError, http.StatusNotFound),
+			),
+			err: otherError,
+		},
+		{
+			testcase: "AnyOf does not fail",
+			status:   http.StatusOK,
+			failManager: client.AnyOf(
+				client.StatusChecker(someError, http.StatusConflict),
+				client.StatusChecker(otherError, http.StatusNotFound),
+			),
+		},
+		{
+			testcase: "AllOf fails when all fail",
+			status:   http.StatusNotFound,
+			header:   http.Header{"X-Error": {"true"}},
+			failManager: client.AllOf(
+				client.StatusChecker(someError, http.StatusNotFound),
+				client.HasHeader(otherError, "X-Error"),
+			),
+			err: someError,
+		},
+		{
+			testcase: "AllOf does not fail when one does not fail",
+			status:   http.StatusNotFound,
+			failManager: client.AllOf(
+				client.StatusChecker(someError, http.StatusNotFound),
+				client.HasHeader(otherError, "X-Error"),
+			),
+		},
+		{
+			testcase:    "Not",
+			status:      http.StatusOK,
+			failManager: client.Not(someError, client.HasHeader(otherError, "X-Version")),
+			err:         someError,
+		},
+		{
+			testcase:    "HeaderChecker",
+			status:      http.StatusOK,
+			header:      http.Header{"X-Status": {"partial"}},
+			failManager: client.HeaderChecker(someError, "X-Status", "failed", "partial"),
+			err:         someError,
+		},
+		{
+			testcase:    "HasProblemDetails",
+			status:      http.StatusForbidden,
+			header:      http.Header{"Content-Type": {"application/problem+json; charset=utf-8"}},
+			body:        problemResponse,
+			failManager: client.HasProblemDetails(someError),
+			err:         someError,
+		},
+		{
+			testcase:    "HasProblemDetails ignores other documents",
+			status:      http.StatusForbidden,
+			header:      http.Header{"Content-Type": {"application/json"}},
+			body:        problemResponse,
+			failManager: client.HasProblemDetails(someError),
+		},
+		{
+			testcase: "body preserved across the checks",
+			status:   http.StatusBadRequest,
+			header:   http.Header{"Content-Type": {"application/problem+json"}},
+			body:     problemResponse,
+			failManager: client.AllOf(
+				client.HasProblemDetails(someError),
+				client.HasValidationErrors(otherError),
+			),
+			err: someError,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.status,
+				Header:     test.header,
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+			}
+			if resp.Header == nil {
+				resp.Header = make(http.Header)
+			}
+
+			err := test.failManager.Check(resp)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != test.body {
+				t.Fatalf("expected %s got %s", test.body, body)
+			}
+		})
+	}
+}
+
+func TestProblemError(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(problemResponse))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	_, err := cli.Get(ctx, server.URL, client.FailOn(client.HasProblemDetails(someError)))
+
+	var problem client.ProblemError
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected %T got %v", problem, err)
+	}
+	if problem.Title != "You do not have enough credit." {
+		t.Fatalf("expected %s got %s", "You do not have enough credit.", problem.Title)
+	}
+	if problem.Status != http.StatusForbidden {
+		t.Fatalf("expected %d got %d", http.StatusForbidden, problem.Status)
+	}
+	if balance := problem.Extensions["balance"]; balance != float64(30) {
+		t.Fatalf("expected %v got %v", 30, balance)
+	}
+	if !errors.Is(err, someError) {
+		t.Fatalf("expected %v got %v", someError, err)
+	}
+}
+
+func TestValidationErrorsLargeBody(t *testing.T) {
+	someError := errors.New("some error")
+	body := validationErrorResponse + strings.Repeat(" ", 2<<20)
+
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+
+	err := client.HasValidationErrors(someError).Check(resp)
+	var validationErrors client.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("expected %T got %v", validationErrors, err)
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+	if len(b) != len(body) {
+		t.Fatalf("expected %d got %d", len(body), len(b))
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	neturl "net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrBodyNotReplayable is returned when a request would have to be sent again
+// but its body is a stream that was already consumed by a previous attempt.
+var ErrBodyNotReplayable = errors.New("Body not replayable")
+
+// notReplayableError is the failure of an attempt that could not be retried
+// because of its body. It unwraps to the failure of the attempt, if any.
+type notReplayableError struct {
+	cause error
+}
+
+func (e notReplayableError) Error() string {
+	if e.cause == nil {
+		return ErrBodyNotReplayable.Error()
+	}
+	return fmt.Sprintf("%v: %v", ErrBodyNotReplayable, e.cause)
+}
+
+func (e notReplayableError) Is(target error) bool {
+	return target == ErrBodyNotReplayable
+}
+
+func (e notReplayableError) Unwrap() error {
+	return e.cause
+}
+
+// FormBody adds an url-encoded form payload to the request, along with the
+// matching Content-Type. The payload is replayed on each retry.
+func FormBody(values neturl.Values) RequestOption {
+	return func(req *Request) error {
+		if err := Header("Content-Type", "application/x-www-form-urlencoded")(req); err != nil {
+			return err
+		}
+		return Body([]byte(values.Encode()))(req)
+	}
+}
+
+// Part is a part of a multipart payload.
+type Part struct {
+	name        string
+	filename    string
+	contentType string
+	value       string
+	content     io.Reader
+}
+
+// FormField is a multipart part holding a form value.
+func FormField(name, value string) Part {
+	return Part{name: name, value: value}
+}
+
+// FormFile is a multipart part holding a file, streamed from content. The
+// content type defaults to application/octet-stream when empty.
+//
+// The content is read once, while the request is being sent: a request with a
+// file part is neither retried nor hedged. When its retry policy asks for
+// another attempt, the request fails with an error matching
+// ErrBodyNotReplayable and wrapping the failure of the attempt.
+func FormFile(name, filename, contentType string, content io.Reader) Part {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return Part{name: name, filename: filename, contentType: contentType, content: content}
+}
+
+// MultipartBody adds a multipart/form-data payload to the request, along with
+// the matching Content-Type. The payload is streamed to the server without
+// being buffered, it is replayed on each retry unless it holds a FormFile.
+func MultipartBody(parts ...Part) RequestOption {
+	return func(req *Request) error {
+		// The boundary is drawn once so that the header is known beforehand.
+		boundary := multipart.NewWriter(io.Discard).Boundary()
+
+		streamed := false
+		for _, part := range parts {
+			streamed = streamed || part.content != nil
+		}
+
+		var sent int32
+		req.getBody = func() (io.Reader, error) {
+			if streamed && !atomic.CompareAndSwapInt32(&sent, 0, 1) {
+				return nil, ErrBodyNotReplayable
+			}
+
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(writeParts(pw, boundary, parts))
+			}()
+			return pr, nil
+		}
+		req.streamed = streamed
+
+		return Header("Content-Type", "multipart/form-data; boundary="+boundary)(req)
+	}
+}
+
+func writeParts(w io.Writer, boundary string, parts []Part) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		if part.content == nil {
+			if err := mw.WriteField(part.name, part.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(part.name), escapeQuotes(part.filename)))
+		header.Set("Content-Type", part.contentType)
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(pw, part.content); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes the names of the Content-Disposition header, the same
+// way as mime/multipart does.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,111 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestFormBody(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			if got := r.PostForm.Get("name"); got != "a&b" {
+				t.Fatalf("expected %s got %s", "a&b", got)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	resp, err := cli.Post(ctx, server.URL, client.FormBody(url.Values{"name": {"a&b"}}))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+}
+
+func TestMultipartBody(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	tests := []struct {
+		testcase      string
+		parts         func() []client.Part
+		expectedCalls int32
+		err           error
+	}{
+		{
+			testcase: "fields only are replayed",
+			parts: func() []client.Part {
+				return []client.Part{client.FormField("title", "report")}
+			},
+			expectedCalls: 2,
+			err:           someError,
+		},
+		{
+			testcase: "files are not replayed",
+			parts: func() []client.Part {
+				return []client.Part{
+					client.FormField("title", "report"),
+					client.FormFile("document", "report.pdf", "application/pdf", strings.NewReader("%PDF")),
+				}
+			},
+			expectedCalls: 1,
+			err:           client.ErrBodyNotReplayable,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&calls, 1)
+					if err := r.ParseMultipartForm(1 << 20); err != nil {
+						t.Fatalf("expected no errors got %v", err)
+					}
+					if got := r.FormValue("title"); got != "report" {
+						t.Fatalf("expected %s got %s", "report", got)
+					}
+					if file, header, err := r.FormFile("document"); err == nil {
+						content, _ := io.ReadAll(file)
+						if string(content) != "%PDF" {
+							t.Fatalf("expected %s got %s", "%PDF", content)
+						}
+						if got := header.Header.Get("Content-Type"); got != "application/pdf" {
+							t.Fatalf("expected %s got %s", "application/pdf", got)
+						}
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			_, err := cli.Post(ctx, server.URL,
+				client.MultipartBody(test.parts()...),
+				client.FailOn(client.StatusChecker(someError, http.StatusServiceUnavailable)),
+				client.Retry(2),
+			)
+			if !errors.Is(err, test.err) || !errors.Is(err, someError) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+			if got := atomic.LoadInt32(&calls); got != test.expectedCalls {
+				t.Fatalf("expected %d got %d", test.expectedCalls, got)
+			}
+		})
+	}
+}
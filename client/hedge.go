@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrHedgeNotIdempotent is raised when hedging is requested on a
+	// non-idempotent method, as the server could process the request twice.
+	ErrHedgeNotIdempotent = errors.New("Hedged requests must be idempotent")
+	// ErrInvalidHedge is raised when hedging is requested with a delay which is
+	// not positive or a negative number of extra attempts.
+	ErrInvalidHedge = errors.New("Invalid hedge parameters")
+)
+
+// Hedge fires up to maxExtra additional identical attempts, one every `after`,
+// as long as none has answered. The first acceptable response (ie. passing the
+// FailManagers) is returned while the other attempts are cancelled. It can only
+// be used on idempotent methods, when given to WithDefaultRequestOptions it
+// only applies to the requests with an idempotent method.
+func Hedge(after time.Duration, maxExtra int) RequestOption {
+	return func(req *Request) error {
+		if !isIdempotent(req.method) {
+			if req.defaults {
+				return nil
+			}
+			return ErrHedgeNotIdempotent
+		}
+		if after <= 0 || maxExtra < 0 {
+			return ErrInvalidHedge
+		}
+		req.hedge = &hedge{after: after, maxExtra: maxExtra}
+		return nil
+	}
+}
+
+type hedge struct {
+	after    time.Duration
+	maxExtra int
+}
+
+type hedgedResult struct {
+	index   int
+	resp    *http.Response
+	snippet []byte
+	err     error
+}
+
+// cancelOnClose cancels the context of a hedged attempt once its body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// send sends an attempt of the request, hedging it if requested.
+func (c client) send(ctx context.Context, req Request) (*http.Response, []byte, error) {
+	// A streamed body can't be sent twice.
+	if req.hedge == nil || req.streamed {
+		return c.try(ctx, req)
+	}
+
+	// Buffered so that the attempts never block once a winner is elected.
+	results := make(chan hedgedResult, req.hedge.maxExtra+1)
+	cancels := make([]context.CancelFunc, 0, req.hedge.maxExtra+1)
+	launch := func() {
+		hedgeCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func(index int) {
+			resp, snippet, err := c.try(hedgeCtx, req)
+			results <- hedgedResult{index: index, resp: resp, snippet: snippet, err: err}
+		}(len(cancels) - 1)
+	}
+
+	timer := time.NewTimer(req.hedge.after)
+	defer timer.Stop()
+
+	launch()
+	pending := 1
+	var last hedgedResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) <= req.hedge.maxExtra {
+				launch()
+				pending++
+				timer.Reset(req.hedge.after)
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.index]()
+				last = res
+				continue
+			}
+
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel()
+				}
+			}
+			go drainHedges(results, pending)
+
+			trace.FromContext(ctx).AddAttributes(
+				trace.Int64Attribute("hedge.winner", int64(res.index)),
+				trace.Int64Attribute("hedge.attempts", int64(len(cancels))),
+			)
+			res.resp.Body = cancelOnClose{res.resp.Body, cancels[res.index]}
+			return res.resp, nil, nil
+		}
+	}
+
+	return last.resp, last.snippet, last.err
+}
+
+// drainHedges closes the responses of the attempts that lost the race.
+func drainHedges(results <-chan hedgedResult, pending int) {
+	for ; pending > 0; pending-- {
+		if res := <-results; res.err == nil {
+			drain(res.resp)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&counter, 1) == 1 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	start := time.Now()
+	cli, _ := client.New()
+	resp, err := cli.Get(ctx, server.URL, client.Hedge(20*time.Millisecond, 2))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if duration := time.Since(start); duration > 500*time.Millisecond {
+		t.Fatalf("expected the hedged attempt to win, took %v", duration)
+	}
+	if got := atomic.LoadInt32(&counter); got != 2 {
+		t.Fatalf("expected %d requests got %d", 2, got)
+	}
+}
+
+func TestHedgeNotIdempotent(t *testing.T) {
+	cli, _ := client.New()
+	_, err := cli.Post(context.Background(), "http://example.invalid",
+		client.Hedge(time.Millisecond, 1))
+	if !errors.Is(err, client.ErrHedgeNotIdempotent) {
+		t.Fatalf("expected %v got %v", client.ErrHedgeNotIdempotent, err)
+	}
+}
+
+func TestDefaultHedgeNotIdempotent(t *testing.T) {
+	counter := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counter, 1)
+			<-time.After(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithDefaultRequestOptions(client.Hedge(10*time.Millisecond, 2)))
+	resp, err := cli.Post(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := atomic.LoadInt32(&counter); got != 1 {
+		t.Fatalf("expected %d request got %d", 1, got)
+	}
+}
+
+func TestInvalidHedge(t *testing.T) {
+	tests := []struct {
+		testcase string
+		after    time.Duration
+		maxExtra int
+	}{
+		{testcase: "negative extra attempts", after: time.Millisecond, maxExtra: -2},
+		{testcase: "zero delay", after: 0, maxExtra: 1},
+	}
+
+	cli, _ := client.New()
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			_, err := cli.Get(context.Background(), "http://example.invalid",
+				client.Hedge(test.after, test.maxExtra))
+			if !errors.Is(err, client.ErrInvalidHedge) {
+				t.Fatalf("expected %v got %v", client.ErrInvalidHedge, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/wrapp/instrumentation/awstraceid"
+	"github.com/wrapp/instrumentation/requestid"
+)
+
+// Doer sends an http request and returns its response.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// DoerFunc is an adapter allowing the use of ordinary functions as Doer.
+type DoerFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Interceptor wraps a Doer to add some behaviour around each attempt of a
+// request (eg. signing, auditing, caching).
+type Interceptor func(next Doer) Doer
+
+// WithInterceptors registers interceptors run around every attempt of every
+// request of the client, in the given order.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *client) error {
+		c.interceptors = append(c.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithoutDefaultInterceptors disables the "battery-included" interceptors
+// injecting the request id, the AWS trace id and the user-agent.
+func WithoutDefaultInterceptors() Option {
+	return func(c *client) error {
+		c.withoutDefaultInterceptors = true
+		return nil
+	}
+}
+
+// Intercept registers interceptors run around every attempt of the request,
+// after the ones of the client.
+func Intercept(interceptors ...Interceptor) RequestOption {
+	return func(req *Request) error {
+		req.interceptors = append(req.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// RequestIDInterceptor injects the request id of the context in the
+// X-Request-ID header.
+func RequestIDInterceptor() Interceptor {
+	return headerInterceptor("X-Request-ID", func(req *http.Request) string {
+		return requestid.Get(req.Context())
+	})
+}
+
+// AWSTraceIDInterceptor injects the AWS trace id of the context in the
+// X-Amzn-Trace-Id header.
+func AWSTraceIDInterceptor() Interceptor {
+	return headerInterceptor(awstraceid.AWSTraceIDHeader, func(req *http.Request) string {
+		return awstraceid.Get(req.Context())
+	})
+}
+
+// UserAgentInterceptor injects the given user-agent.
+func UserAgentInterceptor(ua string) Interceptor {
+	return headerInterceptor("User-Agent", func(*http.Request) string {
+		return ua
+	})
+}
+
+// headerInterceptor sets a header unless it has already been set on the
+// request, so that the request options take precedence.
+func headerInterceptor(key string, value func(*http.Request) string) Interceptor {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(key) == "" {
+				if v := value(req); v != "" {
+					req.Header.Set(key, v)
+				}
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// chain wraps the doer with the interceptors, the first one being the
+// outermost.
+func chain(doer Doer, interceptors ...[]Interceptor) Doer {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		for j := len(interceptors[i]) - 1; j >= 0; j-- {
+			doer = interceptors[i][j](doer)
+		}
+	}
+	return doer
+}
@@ -0,0 +1,104 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wrapp/instrumentation/awstraceid"
+	"github.com/wrapp/instrumentation/client"
+	"github.com/wrapp/instrumentation/requestid"
+)
+
+func recordInterceptor(name string, calls *[]string) client.Interceptor {
+	return func(next client.Doer) client.Doer {
+		return client.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name)
+			return next.Do(req)
+		})
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("X-Signature"); got != "signed" {
+				t.Fatalf("expected signature %s got %s", "signed", got)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	var calls []string
+	sign := func(next client.Doer) client.Doer {
+		return client.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Signature", "signed")
+			return next.Do(req)
+		})
+	}
+
+	cli, _ := client.New(client.WithInterceptors(
+		recordInterceptor("first", &calls),
+		recordInterceptor("second", &calls),
+		sign,
+	))
+	resp, err := cli.Get(ctx, server.URL, client.Intercept(recordInterceptor("request", &calls)))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := strings.Join(calls, ","); got != "first,second,request" {
+		t.Fatalf("expected %s got %s", "first,second,request", got)
+	}
+}
+
+func TestDefaultInterceptors(t *testing.T) {
+	ctx := requestid.Store(context.Background(), "request-id")
+	ctx = awstraceid.Store(ctx, "trace-id")
+
+	tests := []struct {
+		testcase          string
+		options           []client.Option
+		expectedRequestID string
+		expectedTraceID   string
+	}{
+		{
+			testcase:          "should inject the battery-included headers",
+			expectedRequestID: "request-id",
+			expectedTraceID:   "trace-id",
+		},
+		{
+			testcase: "should not inject anything once disabled",
+			options:  []client.Option{client.WithoutDefaultInterceptors()},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("X-Request-ID"); got != test.expectedRequestID {
+						t.Fatalf("expected request-id %q got %q", test.expectedRequestID, got)
+					}
+					if got := r.Header.Get(awstraceid.AWSTraceIDHeader); got != test.expectedTraceID {
+						t.Fatalf("expected trace-id %q got %q", test.expectedTraceID, got)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			defer server.Close()
+
+			cli, _ := client.New(test.options...)
+			resp, err := cli.Get(ctx, server.URL)
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DecodeError is raised when the response body could not be decoded. It
+// carries the status code of the response that failed to be decoded.
+type DecodeError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response body (status %d): %v", e.StatusCode, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// JSONBody marshals the given value and adds it as the payload of the request,
+// along with the matching Content-Type.
+func JSONBody(v interface{}) RequestOption {
+	return func(req *Request) error {
+		buffer, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal json body: %w", err)
+		}
+
+		if err := Header("Content-Type", "application/json")(req); err != nil {
+			return err
+		}
+		return Body(buffer)(req)
+	}
+}
+
+// GetJSON sends a GET request and decodes the JSON response body into T.
+// The returned response body is already consumed and closed.
+func GetJSON[T any](ctx context.Context, c Client, url string, funcs ...RequestOption) (T, Response, error) {
+	return DoJSON[T](ctx, c, http.MethodGet, url, funcs...)
+}
+
+// PostJSON sends a POST request and decodes the JSON response body into T.
+// The returned response body is already consumed and closed.
+func PostJSON[T any](ctx context.Context, c Client, url string, funcs ...RequestOption) (T, Response, error) {
+	return DoJSON[T](ctx, c, http.MethodPost, url, funcs...)
+}
+
+// PutJSON sends a PUT request and decodes the JSON response body into T.
+// The returned response body is already consumed and closed.
+func PutJSON[T any](ctx context.Context, c Client, url string, funcs ...RequestOption) (T, Response, error) {
+	return DoJSON[T](ctx, c, http.MethodPut, url, funcs...)
+}
+
+// PatchJSON sends a PATCH request and decodes the JSON response body into T.
+// The returned response body is already consumed and closed.
+func PatchJSON[T any](ctx context.Context, c Client, url string, funcs ...RequestOption) (T, Response, error) {
+	return DoJSON[T](ctx, c, http.MethodPatch, url, funcs...)
+}
+
+// DeleteJSON sends a DELETE request and decodes the JSON response body into T.
+// The returned response body is already consumed and closed.
+func DeleteJSON[T any](ctx context.Context, c Client, url string, funcs ...RequestOption) (T, Response, error) {
+	return DoJSON[T](ctx, c, http.MethodDelete, url, funcs...)
+}
+
+// DoJSON sends a request with the given method and decodes the JSON response
+// body into T. An empty body decodes into the zero value of T.
+// The returned response body is already consumed and closed.
+func DoJSON[T any](ctx context.Context, c Client, method, url string, funcs ...RequestOption) (T, Response, error) {
+	var got T
+
+	funcs = append([]RequestOption{Header("Accept", "application/json")}, funcs...)
+	resp, err := c.Do(ctx, method, url, funcs...)
+	if err != nil {
+		return got, resp, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil && !errors.Is(err, io.EOF) {
+		return got, resp, DecodeError{StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return got, resp, nil
+}
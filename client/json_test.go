@@ -0,0 +1,113 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestJSONBody(t *testing.T) {
+	ctx := context.Background()
+	expected := payload{ID: "1337", Msg: "yo"}
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("expected content-type application/json got %s", ct)
+			}
+			var got payload
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Fatalf("unable to read body, got %v", err)
+			}
+			if got != expected {
+				t.Fatalf("expected %v got %v", expected, got)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(got)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	got, resp, err := client.PostJSON[payload](ctx, cli, server.URL, client.JSONBody(expected))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+
+	if got != expected {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %d got %d", http.StatusCreated, resp.StatusCode)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase    string
+		statusCode  int
+		body        string
+		expected    payload
+		expectedErr bool
+	}{
+		{
+			testcase:   "should decode the body",
+			statusCode: http.StatusOK,
+			body:       `{"id": "42", "msg": "hello"}`,
+			expected:   payload{ID: "42", Msg: "hello"},
+		},
+		{
+			testcase:   "should return the zero value on an empty body",
+			statusCode: http.StatusNoContent,
+		},
+		{
+			testcase:    "should fail with the status code on an invalid body",
+			statusCode:  http.StatusBadGateway,
+			body:        `<html>bad gateway</html>`,
+			expectedErr: true,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if accept := r.Header.Get("Accept"); accept != "application/json" {
+						t.Fatalf("expected accept application/json got %s", accept)
+					}
+					w.WriteHeader(test.statusCode)
+					_, _ = w.Write([]byte(test.body))
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			got, _, err := client.GetJSON[payload](ctx, cli, server.URL)
+
+			var decodeErr client.DecodeError
+			if test.expectedErr {
+				if !errors.As(err, &decodeErr) {
+					t.Fatalf("expected a DecodeError got %v", err)
+				}
+				if decodeErr.StatusCode != test.statusCode {
+					t.Fatalf("expected status %d got %d", test.statusCode, decodeErr.StatusCode)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			if got != test.expected {
+				t.Fatalf("expected %v got %v", test.expected, got)
+			}
+		})
+	}
+}
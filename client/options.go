@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+// WithBaseURL sets the URL the request URLs are resolved against. Absolute
+// request URLs are left untouched, relative ones are resolved following
+// RFC 3986 (eg. "users/1" against "http://api/v1/" gives "http://api/v1/users/1"
+// while "/users/1" gives "http://api/users/1").
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) error {
+		u, err := neturl.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base url: %w", err)
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithDefaultRequestOptions adds options applied to every request of the
+// client, before the options given on each call.
+func WithDefaultRequestOptions(funcs ...RequestOption) Option {
+	return func(c *client) error {
+		c.defaultOptions = append(c.defaultOptions, funcs...)
+		return nil
+	}
+}
+
+// WithHTTPClient sets the underlying http client. Its transport is still
+// wrapped to trace the outgoing requests, it is used as is otherwise.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) error {
+		c.client = *httpClient
+		return nil
+	}
+}
+
+// WithTransport sets the base transport used to send the requests (eg. custom
+// TLS config, proxies, unix sockets). It is still wrapped to trace the outgoing
+// requests.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *client) error {
+		c.client.Transport = transport
+		return nil
+	}
+}
+
+// WithConnectionPool tunes the connection pool of the default transport. It
+// has no effect when a custom transport or http client is given.
+func WithConnectionPool(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) Option {
+	return func(c *client) error {
+		c.pool = &connectionPool{
+			maxIdleConns:        maxIdleConns,
+			maxIdleConnsPerHost: maxIdleConnsPerHost,
+			idleConnTimeout:     idleConnTimeout,
+		}
+		return nil
+	}
+}
+
+type connectionPool struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+}
+
+// transport returns the default transport, tuned with the pool settings if any.
+func (p *connectionPool) transport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if p == nil {
+		return transport
+	}
+
+	transport.MaxIdleConns = p.maxIdleConns
+	transport.MaxIdleConnsPerHost = p.maxIdleConnsPerHost
+	transport.IdleConnTimeout = p.idleConnTimeout
+	return transport
+}
+
+// resolve resolves the request URL against the base URL of the client, if any.
+func (c client) resolve(url string) (string, error) {
+	if c.baseURL == nil {
+		return url, nil
+	}
+
+	ref, err := neturl.Parse(url)
+	if err != nil {
+		return "", err
+	}
+	return c.baseURL.ResolveReference(ref).String(), nil
+}
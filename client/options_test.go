@@ -0,0 +1,130 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBaseURL(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/users/42" {
+				t.Fatalf("expected path %s got %s", "/v1/users/42", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, err := client.New(client.WithBaseURL(server.URL + "/v1/"))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+
+	resp, err := cli.Get(ctx, "users/42")
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+}
+
+func TestDefaultRequestOptions(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("X-Tenant"); got != r.URL.Query().Get("tenant") {
+				t.Fatalf("expected tenant %s got %s", r.URL.Query().Get("tenant"), got)
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithDefaultRequestOptions(
+		client.Header("X-Tenant", "default"),
+		client.FailOn(client.StatusChecker(someError, http.StatusNotFound)),
+	))
+
+	_, err := cli.Get(ctx, server.URL+"?tenant=default")
+	if !errors.Is(err, someError) {
+		t.Fatalf("expected %v got %v", someError, err)
+	}
+
+	_, err = cli.Get(ctx, server.URL+"?tenant=override", client.Header("X-Tenant", "override"))
+	if !errors.Is(err, someError) {
+		t.Fatalf("expected %v got %v", someError, err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	ctx := context.Background()
+
+	called := false
+	cli, _ := client.New(client.WithTransport(roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			called = true
+			return &http.Response{
+				StatusCode: http.StatusTeapot,
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		})))
+
+	resp, err := cli.Get(ctx, "http://example.invalid")
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if !called {
+		t.Fatalf("expected the transport to be called")
+	}
+	if resp.StatusCode != http.StatusTeapot {
+		t.Fatalf("expected status %d got %d", http.StatusTeapot, resp.StatusCode)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	connections := 0
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections++
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cli, _ := client.New(client.WithConnectionPool(10, 10, time.Minute))
+	for i := 0; i < 5; i++ {
+		resp, err := cli.Get(ctx, server.URL)
+		if err != nil {
+			t.Fatalf("expected no errors got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if connections != 1 {
+		t.Fatalf("expected the connection to be reused, got %d connections", connections)
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrRateLimited is raised when the rate limit would delay the request past
+	// the deadline of its context.
+	ErrRateLimited = errors.New("Rate limited")
+	// ErrInvalidRateLimit is raised when a rate limit is configured with a rate
+	// which is not positive or a burst lower than 1.
+	ErrInvalidRateLimit = errors.New("Invalid rate limit")
+)
+
+// WithRateLimit limits the rate of the requests sent to the host (as in the
+// url, eg. "api.example.com" or "api.example.com:8080") to rps requests per
+// second, with bursts of up to burst requests. An empty host limits the client
+// as a whole. Requests wait for their turn, unless it would exceed the deadline
+// of their context in which case they fail right away with ErrRateLimited.
+func WithRateLimit(host string, rps float64, burst int) Option {
+	return func(c *client) error {
+		if rps <= 0 || burst < 1 {
+			return fmt.Errorf("%w: %v requests per second with bursts of %d", ErrInvalidRateLimit, rps, burst)
+		}
+		if c.limiters == nil {
+			c.limiters = &rateLimiters{hosts: make(map[string]*tokenBucket)}
+		}
+
+		bucket := &tokenBucket{
+			rate:   rps,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+		if host == "" {
+			c.limiters.all = bucket
+		} else {
+			c.limiters.hosts[host] = bucket
+		}
+		return nil
+	}
+}
+
+type rateLimiters struct {
+	all   *tokenBucket
+	hosts map[string]*tokenBucket
+}
+
+// wait blocks until the request to the given url is allowed.
+func (l *rateLimiters) wait(ctx context.Context, u *url.URL) error {
+	if l == nil {
+		return nil
+	}
+
+	buckets := []*tokenBucket{l.all, l.hosts[u.Host]}
+	if u.Hostname() != u.Host {
+		buckets = append(buckets, l.hosts[u.Hostname()])
+	}
+
+	start := time.Now()
+	for i, bucket := range buckets {
+		if bucket == nil {
+			continue
+		}
+		if err := bucket.wait(ctx); err != nil {
+			for _, taken := range buckets[:i] {
+				if taken != nil {
+					taken.release()
+				}
+			}
+			return err
+		}
+	}
+
+	if waited := time.Since(start); waited > time.Millisecond {
+		trace.FromContext(ctx).AddAttributes(
+			trace.Int64Attribute("ratelimit.wait_ms", waited.Milliseconds()),
+		)
+	}
+	return nil
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay, err := b.reserve(ctx)
+	if err != nil {
+		return err
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	if !sleep(ctx, delay) {
+		b.release()
+		return timeoutError{cause: ctx.Err()}
+	}
+	return nil
+}
+
+// reserve takes a token, possibly ahead of time, and returns how long to wait
+// before using it.
+func (b *tokenBucket) reserve(ctx context.Context) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	var delay time.Duration
+	if b.tokens < 1 {
+		delay = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		return 0, ErrRateLimited
+	}
+
+	b.tokens--
+	return delay, nil
+}
+
+// release gives back a token that won't be used.
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
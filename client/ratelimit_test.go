@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		testcase string
+		host     string
+	}{
+		{
+			testcase: "should limit the requests to the host",
+			host:     u.Host,
+		},
+		{
+			testcase: "should limit the requests to the hostname",
+			host:     u.Hostname(),
+		},
+		{
+			testcase: "should limit all the requests of the client",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			cli, _ := client.New(client.WithRateLimit(test.host, 20, 2))
+
+			start := time.Now()
+			for i := 0; i < 4; i++ {
+				resp, err := cli.Get(ctx, server.URL)
+				if err != nil {
+					t.Fatalf("expected no errors got %v", err)
+				}
+				resp.Body.Close()
+			}
+
+			// 2 requests are allowed right away, the 2 others wait 50ms each.
+			if duration := time.Since(start); duration < 90*time.Millisecond {
+				t.Fatalf("expected the requests to be rate limited, took %v", duration)
+			}
+		})
+	}
+}
+
+func TestRateLimitDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithRateLimit("", 1, 1))
+
+	resp, err := cli.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	resp.Body.Close()
+
+	start := time.Now()
+	_, err = cli.Get(context.Background(), server.URL, client.Timeout(100*time.Millisecond))
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expected %v got %v", client.ErrRateLimited, err)
+	}
+	if duration := time.Since(start); duration > 50*time.Millisecond {
+		t.Fatalf("expected to fail fast, took %v", duration)
+	}
+}
+
+func TestInvalidRateLimit(t *testing.T) {
+	tests := []struct {
+		testcase string
+		rps      float64
+		burst    int
+	}{
+		{testcase: "zero rate", rps: 0, burst: 1},
+		{testcase: "negative rate", rps: -1, burst: 1},
+		{testcase: "zero burst", rps: 1, burst: 0},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			_, err := client.New(client.WithRateLimit("", test.rps, test.burst))
+			if !errors.Is(err, client.ErrInvalidRateLimit) {
+				t.Fatalf("expected %v got %v", client.ErrInvalidRateLimit, err)
+			}
+		})
+	}
+}
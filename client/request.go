@@ -4,20 +4,39 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	neturl "net/url"
 	"time"
 )
 
 // Request contains the parameters of the request.
 type Request struct {
-	url          string
-	method       string
-	body         io.Reader
-	headers      map[string]string
-	host         *string
-	maxRetry     *uint
-	backoffRetry *time.Duration
-	timeout      *time.Duration
-	failManagers []FailManager
+	url     string
+	method  string
+	getBody func() (io.Reader, error)
+	// streamed is set when the body can only be read once.
+	streamed       bool
+	headers        map[string]string
+	host           *string
+	retryPolicy    RetryPolicy
+	timeout        *time.Duration
+	attemptTimeout *time.Duration
+	failManagers   []FailManager
+	interceptors   []Interceptor
+	hedge          *hedge
+
+	maxResponseBytes *int64
+	readIdleTimeout  *time.Duration
+	contentEncoding  string
+	acceptEncodings  []string
+	coalesce         *coalesce
+
+	// path is the expanded path template and route the template itself.
+	path  string
+	route string
+	query neturl.Values
+
+	// defaults is set while the default options of the client are applied.
+	defaults bool
 }
 
 // Header adds a header to the request.
@@ -41,10 +60,13 @@ func Host(host string) RequestOption {
 	}
 }
 
-// Body adds a payload to the request.
+// Body adds a payload to the request. The payload is replayed on each retry.
 func Body(buffer []byte) RequestOption {
 	return func(req *Request) error {
-		req.body = bytes.NewBuffer(buffer)
+		req.getBody = func() (io.Reader, error) {
+			return bytes.NewReader(buffer), nil
+		}
+		req.streamed = false
 		return nil
 	}
 }
@@ -76,20 +98,32 @@ func Timeout(duration time.Duration) RequestOption {
 	}
 }
 
-// Retry allows to retry the request multiple time.
-func Retry(count uint) RequestOption {
+// AttemptTimeout adds a timeout to each attempt of the request, unlike
+// Timeout which bounds the request as a whole, retries included.
+func AttemptTimeout(duration time.Duration) RequestOption {
 	return func(req *Request) error {
-		req.maxRetry = &count
+		req.attemptTimeout = &duration
 		return nil
 	}
 }
 
+// Retry allows to retry the request multiple time.
+func Retry(count uint) RequestOption {
+	return WithRetryPolicy(retryCount{maxRetry: count})
+}
+
 // RetryWithBackoff allows to retry the request multiple time, including an
-// exponential backoff.
+// exponential backoff. A random jitter is added on top of each backoff so that
+// concurrent callers do not retry in lockstep.
 func RetryWithBackoff(count uint, backoff time.Duration) RequestOption {
+	return WithRetryPolicy(retryCount{maxRetry: count, backoff: backoff})
+}
+
+// WithRetryPolicy sets the policy deciding whether and when the request is
+// retried. It takes precedence over the default policy of the client.
+func WithRetryPolicy(policy RetryPolicy) RequestOption {
 	return func(req *Request) error {
-		req.maxRetry = &count
-		req.backoffRetry = &backoff
+		req.retryPolicy = policy
 		return nil
 	}
 }
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// math/rand's global source is deterministic unless seeded, which would
+	// make every instance of a service jitter the same way.
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Attempt describes the outcome of a single try of a request.
+type Attempt struct {
+	Method string
+	URL    string
+	// Number is the number of the attempt, starting at 1.
+	Number uint
+	// Response is nil when the request failed before getting a response. Its
+	// body must not be read.
+	Response *http.Response
+	// Err is either the transport error or the error raised by a FailManager.
+	Err error
+}
+
+// RetryPolicy decides whether a request should be retried after an attempt,
+// and how long to wait before the next one.
+type RetryPolicy interface {
+	ShouldRetry(Attempt) bool
+	Delay(Attempt) time.Duration
+}
+
+// WithDefaultRetryPolicy sets the retry policy used by the requests that don't
+// define their own.
+func WithDefaultRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) error {
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+// retryCount retries any failed attempt up to maxRetry attempts, with an
+// optional exponential backoff.
+type retryCount struct {
+	maxRetry uint
+	backoff  time.Duration
+}
+
+func (r retryCount) ShouldRetry(attempt Attempt) bool {
+	return attempt.Err != nil && attempt.Number < r.maxRetry
+}
+
+func (r retryCount) Delay(attempt Attempt) time.Duration {
+	return jitter(time.Duration(math.Pow(2, float64(attempt.Number))-1) * r.backoff)
+}
+
+// RetryOnServerErrors creates a RetryPolicy that retries up to maxAttempts
+// attempts when the server answers with a 5xx or when the connection is reset,
+// without waiting in between. It is meant to be combined with a backoff such as
+// CappedExponentialBackoff.
+func RetryOnServerErrors(maxAttempts uint) RetryPolicy {
+	return serverErrorsPolicy{maxAttempts: maxAttempts}
+}
+
+type serverErrorsPolicy struct {
+	maxAttempts uint
+}
+
+func (p serverErrorsPolicy) ShouldRetry(attempt Attempt) bool {
+	if attempt.Number >= p.maxAttempts {
+		return false
+	}
+
+	if attempt.Response == nil {
+		return isConnectionReset(attempt.Err)
+	}
+
+	return attempt.Response.StatusCode >= http.StatusInternalServerError
+}
+
+func (p serverErrorsPolicy) Delay(Attempt) time.Duration {
+	return 0
+}
+
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IdempotentOnly wraps a RetryPolicy so that requests with a non-idempotent
+// method (eg. POST, PATCH) are never retried.
+func IdempotentOnly(policy RetryPolicy) RetryPolicy {
+	return idempotentPolicy{policy}
+}
+
+type idempotentPolicy struct {
+	RetryPolicy
+}
+
+func (p idempotentPolicy) ShouldRetry(attempt Attempt) bool {
+	return isIdempotent(attempt.Method) && p.RetryPolicy.ShouldRetry(attempt)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// CappedExponentialBackoff wraps a RetryPolicy so that it waits a random
+// duration between 0 and base*2^(attempt-1), capped to max ("full jitter").
+func CappedExponentialBackoff(policy RetryPolicy, base, max time.Duration) RetryPolicy {
+	return cappedExponentialPolicy{RetryPolicy: policy, base: base, max: max}
+}
+
+type cappedExponentialPolicy struct {
+	RetryPolicy
+	base time.Duration
+	max  time.Duration
+}
+
+func (p cappedExponentialPolicy) Delay(attempt Attempt) time.Duration {
+	backoff := p.max
+	if exp := math.Pow(2, float64(attempt.Number)-1) * float64(p.base); exp < float64(p.max) {
+		backoff = time.Duration(exp)
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return time.Duration(jitterRand.Int63n(int64(backoff) + 1))
+}
+
+// jitter adds a random duration of up to half the given backoff.
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 0 {
+		return backoff
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return backoff + time.Duration(jitterRand.Int63n(int64(backoff)/2+1))
+}
+
+// maxRetryAfter bounds the delay a server can request through Retry-After.
+const maxRetryAfter = time.Minute
+
+// retryAfter returns the delay requested by the server through the
+// Retry-After header on a 429 or a 503, up to maxRetryAfter, or 0 if there is
+// none.
+func retryAfter(resp *http.Response) time.Duration {
+	if delay := requestedDelay(resp); delay < maxRetryAfter {
+		return delay
+	}
+	return maxRetryAfter
+}
+
+func requestedDelay(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests &&
+		resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase        string
+		method          string
+		statusCode      int
+		policy          client.RetryPolicy
+		expectedCounter int
+	}{
+		{
+			testcase:        "should retry on server errors",
+			method:          http.MethodGet,
+			statusCode:      http.StatusBadGateway,
+			policy:          client.RetryOnServerErrors(3),
+			expectedCounter: 3,
+		},
+		{
+			testcase:        "should not retry on client errors",
+			method:          http.MethodGet,
+			statusCode:      http.StatusNotFound,
+			policy:          client.RetryOnServerErrors(3),
+			expectedCounter: 1,
+		},
+		{
+			testcase:        "should retry idempotent methods",
+			method:          http.MethodPut,
+			statusCode:      http.StatusInternalServerError,
+			policy:          client.IdempotentOnly(client.RetryOnServerErrors(2)),
+			expectedCounter: 2,
+		},
+		{
+			testcase:        "should not retry non-idempotent methods",
+			method:          http.MethodPost,
+			statusCode:      http.StatusInternalServerError,
+			policy:          client.IdempotentOnly(client.RetryOnServerErrors(3)),
+			expectedCounter: 1,
+		},
+		{
+			testcase:   "should retry with a capped backoff",
+			method:     http.MethodGet,
+			statusCode: http.StatusServiceUnavailable,
+			policy: client.CappedExponentialBackoff(client.RetryOnServerErrors(4),
+				time.Millisecond, 5*time.Millisecond),
+			expectedCounter: 4,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			counter := 0
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					counter++
+					w.WriteHeader(test.statusCode)
+				}))
+			defer server.Close()
+
+			cli, _ := client.New()
+			resp, err := cli.Do(ctx, test.method, server.URL, client.WithRetryPolicy(test.policy))
+			if err != nil {
+				t.Fatalf("expected no errors got %v", err)
+			}
+			defer resp.Body.Close()
+
+			if counter != test.expectedCounter {
+				t.Fatalf("expected %d got %d", test.expectedCounter, counter)
+			}
+			if resp.StatusCode != test.statusCode {
+				t.Fatalf("expected status %d got %d", test.statusCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	counter := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			counter++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	defer server.Close()
+
+	cli, _ := client.New(client.WithDefaultRetryPolicy(client.RetryOnServerErrors(3)))
+
+	resp, err := cli.Get(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	resp.Body.Close()
+	if counter != 3 {
+		t.Fatalf("expected %d got %d", 3, counter)
+	}
+
+	counter = 0
+	resp, err = cli.Get(ctx, server.URL, client.Retry(0))
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	resp.Body.Close()
+	if counter != 1 {
+		t.Fatalf("the request policy should take precedence, expected %d got %d", 1, counter)
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// The measures recorded by the client.
+var (
+	MeasureLatency = stats.Float64("wrapp/client/latency",
+		"Latency of the requests, retries included", stats.UnitMilliseconds)
+	MeasureRequestBytes = stats.Int64("wrapp/client/request_bytes",
+		"Size of the request bodies sent, per attempt", stats.UnitBytes)
+	MeasureResponseBytes = stats.Int64("wrapp/client/response_bytes",
+		"Size of the response bodies read, per attempt", stats.UnitBytes)
+	MeasureRetries = stats.Int64("wrapp/client/retries",
+		"Number of retries of the requests", stats.UnitDimensionless)
+	MeasureTimeouts = stats.Int64("wrapp/client/timeouts",
+		"Number of requests and attempts that timed out", stats.UnitDimensionless)
+	MeasureCircuitOpen = stats.Int64("wrapp/client/circuit_open",
+		"Number of attempts rejected by an open circuit breaker", stats.UnitDimensionless)
+)
+
+// The tags of the measures. The route is the path template set with Path, so
+// that the cardinality remains bounded, the status is "error" when no response
+// was received.
+var (
+	KeyHost   = tag.MustNewKey("http_client_host")
+	KeyMethod = tag.MustNewKey("http_client_method")
+	KeyRoute  = tag.MustNewKey("http_client_route")
+	KeyStatus = tag.MustNewKey("http_client_status")
+)
+
+// The views of the measures, registered by RegisterViews.
+var (
+	LatencyView = &view.View{
+		Name:        "wrapp/client/latency",
+		Description: "Latency distribution of the requests, retries included",
+		Measure:     MeasureLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{KeyHost, KeyMethod, KeyRoute, KeyStatus},
+	}
+	RequestBytesView = &view.View{
+		Name:        "wrapp/client/request_bytes",
+		Description: "Size distribution of the request bodies",
+		Measure:     MeasureRequestBytes,
+		Aggregation: ochttp.DefaultSizeDistribution,
+		TagKeys:     []tag.Key{KeyHost, KeyMethod, KeyRoute},
+	}
+	ResponseBytesView = &view.View{
+		Name:        "wrapp/client/response_bytes",
+		Description: "Size distribution of the response bodies",
+		Measure:     MeasureResponseBytes,
+		Aggregation: ochttp.DefaultSizeDistribution,
+		TagKeys:     []tag.Key{KeyHost, KeyMethod, KeyRoute, KeyStatus},
+	}
+	RetriesView = &view.View{
+		Name:        "wrapp/client/retries",
+		Description: "Total number of retries",
+		Measure:     MeasureRetries,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{KeyHost, KeyMethod, KeyRoute, KeyStatus},
+	}
+	TimeoutsView = &view.View{
+		Name:        "wrapp/client/timeouts",
+		Description: "Number of requests and attempts that timed out",
+		Measure:     MeasureTimeouts,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyHost, KeyMethod, KeyRoute},
+	}
+	CircuitOpenView = &view.View{
+		Name:        "wrapp/client/circuit_open",
+		Description: "Number of attempts rejected by an open circuit breaker",
+		Measure:     MeasureCircuitOpen,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyHost},
+	}
+
+	DefaultViews = []*view.View{
+		LatencyView,
+		RequestBytesView,
+		ResponseBytesView,
+		RetriesView,
+		TimeoutsView,
+		CircuitOpenView,
+	}
+)
+
+// RegisterViews registers the views of the client, they are then exported by
+// the registered OpenCensus exporters.
+func RegisterViews() error {
+	return view.Register(DefaultViews...)
+}
+
+// requestTags are the tags describing a request.
+func requestTags(method, host, route string) []tag.Mutator {
+	tags := []tag.Mutator{
+		tag.Upsert(KeyHost, host),
+		tag.Upsert(KeyMethod, method),
+	}
+	if route != "" {
+		tags = append(tags, tag.Upsert(KeyRoute, route))
+	}
+	return tags
+}
+
+func statusTag(statusCode int) tag.Mutator {
+	if statusCode == 0 {
+		return tag.Upsert(KeyStatus, "error")
+	}
+	return tag.Upsert(KeyStatus, strconv.Itoa(statusCode))
+}
+
+// recordRequest records the outcome of a request, retries included.
+func recordRequest(ctx context.Context, call *call, statusCode int, elapsed time.Duration) {
+	tags := append(requestTags(call.req.method, call.host, call.req.route), statusTag(statusCode))
+	measurements := []stats.Measurement{
+		MeasureLatency.M(float64(elapsed) / float64(time.Millisecond)),
+	}
+	if attempts := call.attemptCount(); attempts > 1 {
+		measurements = append(measurements, MeasureRetries.M(int64(attempts-1)))
+	}
+	_ = stats.RecordWithTags(ctx, tags, measurements...)
+}
+
+func recordTimeout(ctx context.Context, call *call) {
+	_ = stats.RecordWithTags(ctx, requestTags(call.req.method, call.host, call.req.route),
+		MeasureTimeouts.M(1))
+}
+
+func recordCircuitOpen(ctx context.Context, host string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(KeyHost, host)}, MeasureCircuitOpen.M(1))
+}
+
+func recordRequestBytes(ctx context.Context, request Request, host string, n int64) {
+	_ = stats.RecordWithTags(ctx, requestTags(request.method, host, request.route),
+		MeasureRequestBytes.M(n))
+}
+
+func recordResponseBytes(ctx context.Context, request Request, host string, statusCode int, n int64) {
+	tags := append(requestTags(request.method, host, request.route), statusTag(statusCode))
+	_ = stats.RecordWithTags(ctx, tags, MeasureResponseBytes.M(n))
+}
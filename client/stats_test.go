@@ -0,0 +1,140 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wrapp/instrumentation/client"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// rowFor returns the row of the view holding the given tag values.
+func rowFor(t *testing.T, v *view.View, tags map[tag.Key]string) *view.Row {
+	t.Helper()
+
+	rows, err := view.RetrieveData(v.Name)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	for _, row := range rows {
+		matches := 0
+		for _, tg := range row.Tags {
+			if value, ok := tags[tg.Key]; ok && value == tg.Value {
+				matches++
+			}
+		}
+		if matches == len(tags) {
+			return row
+		}
+	}
+	t.Fatalf("expected a row for %v got %v", tags, rows)
+	return nil
+}
+
+func TestRegisterViews(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	if err := client.RegisterViews(); err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	defer view.Unregister(client.DefaultViews...)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch atomic.AddInt32(&calls, 1) {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				_, _ = w.Write([]byte("hello"))
+			default:
+				time.Sleep(50 * time.Millisecond)
+			}
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+	resp, err := cli.Post(ctx, server.URL,
+		client.Path("/users/{id}", "id", "42"),
+		client.Body([]byte("payload")),
+		client.FailOn(client.StatusChecker(someError, http.StatusServiceUnavailable)),
+		client.Retry(2),
+	)
+	if err != nil {
+		t.Fatalf("expected no errors got %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	_, err = cli.Get(ctx, server.URL, client.Path("/users/{id}", "id", "42"), client.Timeout(10*time.Millisecond))
+	if !errors.Is(err, client.ErrTimeout) {
+		t.Fatalf("expected %v got %v", client.ErrTimeout, err)
+	}
+
+	route := map[tag.Key]string{client.KeyRoute: "/users/{id}", client.KeyMethod: http.MethodPost}
+	success := map[tag.Key]string{client.KeyRoute: "/users/{id}", client.KeyStatus: "200"}
+	tests := []struct {
+		testcase string
+		view     *view.View
+		tags     map[tag.Key]string
+		expected float64
+		got      func(view.AggregationData) float64
+	}{
+		{
+			testcase: "latency",
+			view:     client.LatencyView,
+			tags:     success,
+			expected: 1,
+			got:      func(d view.AggregationData) float64 { return float64(d.(*view.DistributionData).Count) },
+		},
+		{
+			testcase: "retries",
+			view:     client.RetriesView,
+			tags:     success,
+			expected: 1,
+			got:      func(d view.AggregationData) float64 { return d.(*view.SumData).Value },
+		},
+		{
+			testcase: "request bytes",
+			view:     client.RequestBytesView,
+			tags:     route,
+			expected: 2 * float64(len("payload")),
+			got: func(d view.AggregationData) float64 {
+				data := d.(*view.DistributionData)
+				return data.Mean * float64(data.Count)
+			},
+		},
+		{
+			testcase: "response bytes",
+			view:     client.ResponseBytesView,
+			tags:     success,
+			expected: float64(len("hello")),
+			got:      func(d view.AggregationData) float64 { return d.(*view.DistributionData).Mean },
+		},
+		{
+			testcase: "timeouts",
+			view:     client.TimeoutsView,
+			tags:     map[tag.Key]string{client.KeyRoute: "/users/{id}", client.KeyMethod: http.MethodGet},
+			expected: 1,
+			got:      func(d view.AggregationData) float64 { return float64(d.(*view.CountData).Value) },
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			row := rowFor(t, test.view, test.tags)
+			if got := test.got(row.Data); got != test.expected {
+				t.Fatalf("expected %v got %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"strings"
+)
+
+// ErrInvalidPath is returned when a path template cannot be expanded.
+var ErrInvalidPath = errors.New("Invalid path template")
+
+// routeKey is the context key holding the path template of the request, so
+// that the transport can name its span after it.
+type routeKey struct{}
+
+// Query adds a query parameter to the request URL, the value is escaped.
+func Query(key, value string) RequestOption {
+	return QueryValues(neturl.Values{key: {value}})
+}
+
+// QueryValues adds query parameters to the request URL, the values are
+// escaped. The parameters already in the URL are kept.
+func QueryValues(values neturl.Values) RequestOption {
+	return func(req *Request) error {
+		if req.query == nil {
+			req.query = make(neturl.Values)
+		}
+		for key, vals := range values {
+			for _, val := range vals {
+				req.query.Add(key, val)
+			}
+		}
+		return nil
+	}
+}
+
+// Path sets the path of the request URL from a template such as
+// "/users/{id}", where each placeholder is replaced by the escaped value
+// following its name in params:
+//
+//	cli.Get(ctx, "", client.Path("/users/{id}", "id", userID))
+//
+// The path is resolved against the request URL the same way as with
+// WithBaseURL, keeping its query. The template, rather than the expanded path,
+// names the span and the errors of the request so that their cardinality
+// remains bounded.
+func Path(template string, params ...string) RequestOption {
+	return func(req *Request) error {
+		path, err := expand(template, params)
+		if err != nil {
+			return err
+		}
+		req.path = path
+		req.route = template
+		return nil
+	}
+}
+
+func expand(template string, params []string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("%w %q: missing value of parameter %q",
+			ErrInvalidPath, template, params[len(params)-1])
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	var path strings.Builder
+	used := make(map[string]bool, len(values))
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			path.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w %q: unclosed placeholder", ErrInvalidPath, template)
+		}
+		name := rest[start+1 : start+end]
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("%w %q: missing parameter %q", ErrInvalidPath, template, name)
+		}
+		// Dot segments would be removed when resolving the path, moving the
+		// request elsewhere.
+		if value == "." || value == ".." {
+			return "", fmt.Errorf("%w %q: dot segment value of parameter %q", ErrInvalidPath, template, name)
+		}
+		used[name] = true
+		path.WriteString(rest[:start])
+		path.WriteString(neturl.PathEscape(value))
+		rest = rest[start+end+1:]
+	}
+
+	if len(used) < len(values) {
+		return "", fmt.Errorf("%w %q: unused parameters", ErrInvalidPath, template)
+	}
+	return path.String(), nil
+}
+
+// buildURL applies the path and the query parameters to the request URL.
+func (req *Request) buildURL() error {
+	if req.path == "" && len(req.query) == 0 {
+		return nil
+	}
+
+	u, err := neturl.Parse(req.url)
+	if err != nil {
+		return err
+	}
+
+	if req.path != "" {
+		ref, err := neturl.Parse(req.path)
+		if err != nil {
+			return fmt.Errorf("%w %q: %v", ErrInvalidPath, req.route, err)
+		}
+		rawQuery := u.RawQuery
+		u = u.ResolveReference(ref)
+		u.RawQuery = rawQuery
+	}
+
+	if len(req.query) > 0 {
+		query := u.Query()
+		for key, vals := range req.query {
+			query[key] = append(query[key], vals...)
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	req.url = u.String()
+	return nil
+}
+
+// withRoute stores the path template of the request in the context.
+func withRoute(ctx context.Context, route string) context.Context {
+	if route == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// spanName names the span of the request after its method and path template,
+// if any.
+func (c client) spanName(ctx context.Context, method string) string {
+	route, ok := ctx.Value(routeKey{}).(string)
+	if !ok {
+		return c.spanNameFormat
+	}
+	return fmt.Sprintf("%s %s %s", c.spanNameFormat, method, route)
+}
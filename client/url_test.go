@@ -0,0 +1,166 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wrapp/instrumentation/client"
+	"go.opencensus.io/trace"
+)
+
+func TestPathAndQuery(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		testcase string
+		url      string
+		options  []client.RequestOption
+		expected string
+		err      error
+	}{
+		{
+			testcase: "escaped path parameters",
+			url:      "/",
+			options:  []client.RequestOption{client.Path("/users/{id}/files/{name}", "id", "a/b", "name", "c d")},
+			expected: "/users/a%2Fb/files/c%20d",
+		},
+		{
+			testcase: "relative path template",
+			url:      "/v1/",
+			options:  []client.RequestOption{client.Path("users/{id}", "id", "42")},
+			expected: "/v1/users/42",
+		},
+		{
+			testcase: "query parameters whatever the options order",
+			url:      "/?a=1",
+			options: []client.RequestOption{
+				client.Query("b", "x&y"),
+				client.Path("/users/{id}", "id", "42"),
+				client.QueryValues(url.Values{"a": {"2"}}),
+			},
+			expected: "/users/42?a=1&a=2&b=x%26y",
+		},
+		{
+			testcase: "missing parameter",
+			url:      "/",
+			options:  []client.RequestOption{client.Path("/users/{id}", "name", "42")},
+			err:      client.ErrInvalidPath,
+		},
+		{
+			testcase: "missing parameter value",
+			url:      "/",
+			options:  []client.RequestOption{client.Path("/users/{id}", "id")},
+			err:      client.ErrInvalidPath,
+		},
+		{
+			testcase: "unused parameter of a repeated placeholder",
+			url:      "/",
+			options:  []client.RequestOption{client.Path("/users/{id}/{id}", "id", "1", "x", "2")},
+			err:      client.ErrInvalidPath,
+		},
+		{
+			testcase: "dot segment value",
+			url:      "/api/v1/",
+			options:  []client.RequestOption{client.Path("users/{id}/profile", "id", "..")},
+			err:      client.ErrInvalidPath,
+		},
+		{
+			testcase: "unclosed placeholder",
+			url:      "/",
+			options:  []client.RequestOption{client.Path("/users/{id", "id", "42")},
+			err:      client.ErrInvalidPath,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.URL.RequestURI()))
+		}))
+	defer server.Close()
+
+	cli, _ := client.New()
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.testcase, func(t *testing.T) {
+			resp, err := cli.Get(ctx, server.URL+test.url, test.options...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			var body [256]byte
+			n, _ := resp.Body.Read(body[:])
+			if got := string(body[:n]); got != test.expected {
+				t.Fatalf("expected %s got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, s.Name)
+}
+
+func TestPathRoute(t *testing.T) {
+	ctx := context.Background()
+	someError := errors.New("some error")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	defer server.Close()
+
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	cli, _ := client.New()
+	_, err := cli.Get(ctx, server.URL,
+		client.Path("/users/{id}", "id", "42"),
+		client.FailOn(client.StatusChecker(someError, http.StatusNotFound)),
+		// Sampling the parent span so that the span of the transport is too.
+		client.Intercept(func(next client.Doer) client.Doer {
+			return client.DoerFunc(func(r *http.Request) (*http.Response, error) {
+				ctx, span := trace.StartSpan(r.Context(), "test", trace.WithSampler(trace.AlwaysSample()))
+				defer span.End()
+				return next.Do(r.WithContext(ctx))
+			})
+		}),
+	)
+
+	var reqErr client.RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected %T got %v", reqErr, err)
+	}
+	if reqErr.Route != "/users/{id}" {
+		t.Fatalf("expected %s got %s", "/users/{id}", reqErr.Route)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	expected := " GET /users/{id}"
+	for _, name := range recorder.names {
+		if strings.HasSuffix(name, expected) {
+			return
+		}
+	}
+	t.Fatalf("expected span %q got %v", expected, recorder.names)
+}
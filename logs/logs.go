@@ -2,6 +2,7 @@ package logs
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strings"
 
@@ -106,6 +107,23 @@ func WithAWSTraceID(ctx context.Context) func(zerolog.Context) zerolog.Context {
 	}
 }
 
+// WithError injects the error in the logs, along with its structured fields
+// when it (or any error it wraps) implements zerolog.LogObjectMarshaler, eg. a
+// client.RequestError.
+func WithError(err error) func(zerolog.Context) zerolog.Context {
+	return func(log zerolog.Context) zerolog.Context {
+		if err == nil {
+			return log
+		}
+		log = log.Err(err)
+		var fields zerolog.LogObjectMarshaler
+		if errors.As(err, &fields) {
+			log = log.EmbedObject(fields)
+		}
+		return log
+	}
+}
+
 // MaskSSN masks the SSN from the logs.
 func MaskSSN(ssn string) string {
 	if len(ssn) < 4 {
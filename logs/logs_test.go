@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/wrapp/instrumentation/awstraceid"
 	"github.com/wrapp/instrumentation/logs"
 	"github.com/wrapp/instrumentation/requestid"
@@ -79,3 +81,38 @@ func TestNew(t *testing.T) {
 		t.Fatalf("expected %v got %v", expected, got)
 	}
 }
+
+type fieldsError struct{}
+
+func (fieldsError) Error() string { return "fields error" }
+
+func (fieldsError) MarshalZerologObject(e *zerolog.Event) {
+	e.Int("status", 500)
+}
+
+func TestWithError(t *testing.T) {
+	type log struct {
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}
+
+	expected := log{
+		Error:  "wrapped: fields error",
+		Status: 500,
+	}
+
+	var out bytes.Buffer
+	err := fmt.Errorf("wrapped: %w", fieldsError{})
+	logger := logs.New(context.Background(), logs.WithError(err)).Output(&out)
+
+	logger.Info().Msg("my-message")
+
+	var got log
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("got an unexpected error %v", err)
+	}
+
+	if got != expected {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+}